@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var unassign bool
+
+var assignCmd = &cobra.Command{
+	Use:   "assign <issueID> [user]",
+	Short: "Assign an issue to a teammate",
+	Long: `assign assigns an issue to a member of its team. The user can be given by
+name, display name, email or @handle, ignoring case; close matches are only
+suggested, and offered by shell completion. Use 'me' for yourself.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if unassign {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 || unassign {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, client, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		members, err := fetchTeamMembers(ctx, client, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return completeUsers(members, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		issueID := args[0]
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+
+		if unassign {
+			resp, err := generated.IssueUnassign(ctx, client, issueID)
+			if err != nil {
+				return issueError(err, issueID)
+			}
+			issue := resp.IssueUpdate.Issue
+			if issue == nil {
+				invalidateIssueCache(issueID)
+				return fmt.Errorf("issue %s could not be updated", issueID)
+			}
+			invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+			fmt.Printf("Success! Unassigned %v\n", issue.Title)
+			return nil
+		}
+
+		var user generated.UserFields
+		if strings.EqualFold(args[1], "me") {
+			user, err = resolveUser(ctx, client, "me")
+		} else {
+			var members []generated.UserFields
+			members, err = fetchTeamMembers(ctx, client, issueID)
+			if err == nil {
+				user, err = matchUser(members, args[1])
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		resp, err := generated.IssueUpdate(ctx, client, issueID, generated.IssueUpdateInput{AssigneeId: &user.Id})
+		if err != nil {
+			return issueError(err, issueID)
+		}
+		issue := resp.IssueUpdate.Issue
+		if issue == nil {
+			invalidateIssueCache(issueID)
+			return fmt.Errorf("issue %s could not be updated", issueID)
+		}
+		invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+		fmt.Printf("Success! Assigned %v to %v\n", user.Name, issue.Title)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(assignCmd)
+
+	assignCmd.Flags().BoolVar(&unassign, "unassign", false, "Remove the issue's assignee")
+}
+
+// fetchTeamMembers returns the active members of issueID's team. Like
+// issueTeamStates, members are cached by team key.
+func fetchTeamMembers(ctx context.Context, client graphql.Client, issueID string) ([]generated.UserFields, error) {
+	fetch := func() ([]generated.UserFields, error) {
+		return fetchPages(func(after *string) ([]generated.UserFields, generated.PageInfoFields, error) {
+			response, err := generated.TeamMembers(ctx, client, issueID, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, issueError(err, issueID)
+			}
+			nodes := response.Issue.Team.Members.Nodes
+			members := make([]generated.UserFields, len(nodes))
+			for i, n := range nodes {
+				members[i] = n.UserFields
+			}
+			return members, response.Issue.Team.Members.PageInfo.PageInfoFields, nil
+		})
+	}
+
+	m := issueKeyPattern.FindStringSubmatch(issueID)
+	if m == nil {
+		return fetch()
+	}
+	members, _, err := cached("users-team-"+strings.ToUpper(m[1]), fetch)
+	return members, err
+}
+
+// matchUser picks the active user that best matches query (see rankUsers).
+// When several users match equally well it refuses to guess and lists them.
+func matchUser(users []generated.UserFields, query string) (generated.UserFields, error) {
+	if strings.TrimPrefix(strings.TrimSpace(query), "@") == "" {
+		return generated.UserFields{}, fmt.Errorf("no user given")
+	}
+
+	ranked := scoreUsers(users, query)
+	switch {
+	case len(ranked) == 0:
+		return generated.UserFields{}, fmt.Errorf("no user matches %q", query)
+	case len(ranked) == 1 || ranked[1].score < ranked[0].score:
+		return ranked[0].user, nil
+	}
+	var tied []generated.UserFields
+	for _, r := range ranked {
+		if r.score == ranked[0].score {
+			tied = append(tied, r.user)
+		}
+	}
+	return generated.UserFields{}, fmt.Errorf("%q matches several users: %s", query, userNames(tied))
+}
+
+type rankedUser struct {
+	user  generated.UserFields
+	score int
+}
+
+// scoreUsers scores the active users against query by name, display name,
+// email and email name, best first, dropping those that don't match at all.
+// Exact matches beat prefixes, which beat word prefixes, substrings and
+// finally letters in order ("jdoe" → "Jane Doe").
+func scoreUsers(users []generated.UserFields, query string) []rankedUser {
+	q := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query), "@"))
+	var matches []rankedUser
+	for _, u := range users {
+		if !u.Active {
+			continue
+		}
+		localPart, _, _ := strings.Cut(u.Email, "@")
+		score := 0
+		for _, field := range []string{u.Name, u.DisplayName, u.Email, localPart} {
+			score = max(score, matchScore(strings.ToLower(field), q))
+		}
+		if score > 0 || q == "" {
+			matches = append(matches, rankedUser{u, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return matches
+}
+
+// rankUsers returns the active users that match query, best first.
+func rankUsers(users []generated.UserFields, query string) []generated.UserFields {
+	ranked := scoreUsers(users, query)
+	result := make([]generated.UserFields, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.user
+	}
+	return result
+}
+
+// userNames lists users as "Jane Doe (@jdoe), …" in alphabetical order.
+func userNames(users []generated.UserFields) string {
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = fmt.Sprintf("%s (@%s)", u.Name, u.DisplayName)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// completeUsers offers users for completion, closest to toComplete first.
+func completeUsers(users []generated.UserFields, toComplete string) []string {
+	completions := []string{"me\tYourself"}
+	for _, u := range rankUsers(users, toComplete) {
+		completions = append(completions, "@"+u.DisplayName+"\t"+u.Name)
+	}
+	return completions
+}
+
+// matchScore rates how well q matches s; 0 means not at all.
+func matchScore(s, q string) int {
+	switch {
+	case s == "":
+		return 0
+	case s == q:
+		return 5
+	case strings.HasPrefix(s, q):
+		return 4
+	case strings.Contains(" "+s, " "+q):
+		return 3
+	case strings.Contains(s, q):
+		return 2
+	case isSubsequence(s, q):
+		return 1
+	}
+	return 0
+}
+
+func isSubsequence(s, q string) bool {
+	rest := []rune(q)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
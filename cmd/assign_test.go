@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+func TestMatchUser(t *testing.T) {
+	users := []generated.UserFields{
+		{Id: "u1", Name: "Jane Doe", DisplayName: "jane", Email: "jane@example.com", Active: true},
+		{Id: "u2", Name: "Jonathan Smith", DisplayName: "jsmith", Email: "jonathan@example.com", Active: true},
+		{Id: "u3", Name: "Jo Park", DisplayName: "jo", Email: "jo.park@example.com", Active: true},
+		{Id: "u4", Name: "Joanna Gone", DisplayName: "joanna", Email: "joanna@example.com", Active: false},
+	}
+
+	tests := []struct {
+		name    string
+		users   []generated.UserFields
+		query   string
+		want    string
+		wantErr string
+	}{
+		{name: "exact name", users: users, query: "Jane Doe", want: "u1"},
+		{name: "exact handle", users: users, query: "@jsmith", want: "u2"},
+		{name: "exact beats prefix", users: users, query: "jo", want: "u3"},
+		{name: "unique prefix", users: users[:2], query: "jo", want: "u2"},
+		{name: "letters in order", users: users, query: "jdoe", want: "u1"},
+		{name: "inactive users are ignored", users: users, query: "joanna", wantErr: `no user matches "joanna"`},
+		{name: "ambiguous", users: users, query: "j", wantErr: `"j" matches several users: Jane Doe (@jane), Jo Park (@jo), Jonathan Smith (@jsmith)`},
+		{name: "none", users: users, query: "zed", wantErr: `no user matches "zed"`},
+		{name: "empty", users: users, query: " @ ", wantErr: "no user given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchUser(tt.users, tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("matchUser(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchUser(%q): %v", tt.query, err)
+			}
+			if got.Id != tt.want {
+				t.Errorf("matchUser(%q) = %s, want %s", tt.query, got.Id, tt.want)
+			}
+		})
+	}
+}
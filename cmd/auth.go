@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 
-	"github.com/Khan/genqlient/graphql"
 	"github.com/rangoons/quick-branch/internal/generated"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,12 +19,32 @@ import (
 var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Store your Linear API key",
-	Long: `Store your Linear API key in the config file for future use.
+	Long: `Store your Linear API key for future use.
 The API key will be hidden while you type or paste it.
 
+By default the key is kept in the OS keyring when one is available and in an
+encrypted file otherwise; the config file only records where it lives. Use
+--store to choose explicitly:
+
+  keyring          OS keyring (Secret Service, Keychain, Credential Manager)
+  file             AES-GCM encrypted file, unlocked with a passphrase
+  exec:<command>   credential helper run as "<command> get|store|erase"
+  config           plain text in config.yaml (not recommended)
+
+Use --oauth to sign in through Linear's OAuth2 flow instead of pasting a
+personal API key. This needs an OAuth application whose callback URL is
+http://localhost:53682/callback (change the port with oauth.redirect_port).
+The access token is refreshed automatically when it expires.
+
 Example:
-  linear-cli auth`,
+  quick-branch auth
+  quick-branch auth --store exec:pass-quick-branch
+  quick-branch auth --oauth --client-id <client id>`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if authOAuth {
+			return runOAuthAuth(cmd.Context())
+		}
+
 		fmt.Print("Enter your Linear API key: ")
 
 		apiKeyBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
@@ -40,78 +61,262 @@ Example:
 
 		// Test the API key before saving
 		fmt.Println("Verifying API key...")
-		if err := verifyAPIKey(apiKey); err != nil {
+		if _, err := verifyAPIKey(cmd.Context(), apiKey); err != nil {
 			return fmt.Errorf("API key verification failed: %w", err)
 		}
 
-		return writeAPIToken(apiKey)
+		return saveCredential(apiKey, authStore, authTypeAPIKey)
+	},
+}
+
+var (
+	authStore    string
+	authOAuth    bool
+	authClientID string
+)
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which Linear account quick-branch is using",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cred, err := loadCredential()
+		if err != nil {
+			return err
+		}
+		transport, err := newAuthorizedTransport(cred)
+		if err != nil {
+			return err
+		}
+		client := newLinearClient(transport)
+		response, err := generated.Me(cmd.Context(), client)
+		if err != nil {
+			return fmt.Errorf("credentials from %s were rejected: %w", cred.describe(), err)
+		}
+
+		authType := "personal API key"
+		scopes := "not reported for personal API keys"
+		if cred.isOAuth() {
+			var token oauthToken
+			if err := json.Unmarshal([]byte(cred.secret), &token); err == nil {
+				authType = "OAuth"
+				if !token.Expiry.IsZero() {
+					authType += fmt.Sprintf(" (expires %s)", token.Expiry.Local().Format("2006-01-02 15:04"))
+				}
+				scopes = token.Scope
+			}
+		}
+
+		viewer := response.Viewer
+		fmt.Println("✓ Logged in to Linear")
+		fmt.Println()
+		fmt.Printf("  Name:      %s\n", viewer.Name)
+		fmt.Printf("  Email:     %s\n", viewer.Email)
+		fmt.Printf("  Workspace: %s (linear.app/%s)\n", viewer.Organization.Name, viewer.Organization.UrlKey)
+		fmt.Printf("  Auth:      %s\n", authType)
+		fmt.Printf("  Source:    %s\n", cred.describe())
+		fmt.Printf("  Scopes:    %s\n", scopes)
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove your stored Linear credentials",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed := false
+
+		store, err := configuredSecretStore()
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			switch err := store.Delete(); {
+			case err == nil:
+				removed = true
+				fmt.Printf("✓ Removed credentials from %s\n", credential{source: store.String()}.describe())
+			case !errors.Is(err, errSecretNotFound):
+				return fmt.Errorf("failed to remove credentials from %s: %w", store, err)
+			}
+		}
+
+		if viper.InConfig("api_key") {
+			if err := removeConfigKey("api_key"); err != nil {
+				return err
+			}
+			removed = true
+			fmt.Println("✓ Removed API key from the config file")
+		}
+		for _, key := range []string{"credential_store", "auth_type"} {
+			if err := removeConfigKey(key); err != nil {
+				return err
+			}
+		}
+		invalidateCache()
+
+		if viper.GetString("api_key") != "" {
+			fmt.Println("! An API key is still set in your environment (QUICK_BRANCH_API_KEY); unset it to finish logging out.")
+		} else if !removed {
+			fmt.Println("No stored credentials found.")
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.Flags().StringVar(&authStore, "store", "", "Where to keep the API key: keyring, file, config or exec:<command> (default: most secure available)")
+	authCmd.Flags().BoolVar(&authOAuth, "oauth", false, "Sign in with Linear OAuth instead of a personal API key")
+	authCmd.Flags().StringVar(&authClientID, "client-id", "", "OAuth application client ID (overrides oauth.client_id)")
 }
 
-// authorizedTransport adds the Authorization header to all requests
+// authorizedTransport adds the Authorization header to all requests. With a
+// personal API key the key is sent as-is; with OAuth a bearer token is sent
+// and refreshed whenever it expires.
 type authorizedTransport struct {
 	apiKey string
+	oauth  *oauthSource
 	base   http.RoundTripper
 }
 
 func (t *authorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", t.apiKey)
+	if t.oauth != nil {
+		token, err := t.oauth.accessToken(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("Authorization", t.apiKey)
+	}
 	return t.base.RoundTrip(req)
 }
 
-func verifyAPIKey(apiKey string) error {
-	httpClient := &http.Client{
-		Transport: &authorizedTransport{
-			apiKey: apiKey,
-			base:   http.DefaultTransport,
-		},
+// newAuthorizedTransport builds the transport for a stored credential.
+func newAuthorizedTransport(cred credential) (*authorizedTransport, error) {
+	t := &authorizedTransport{base: withDebug(http.DefaultTransport)}
+	if cred.isOAuth() {
+		var err error
+		t.oauth, err = newOAuthSource(cred.secret, cred.store)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		t.apiKey = cred.secret
+	}
+	return t, nil
+}
+
+// activeOAuth is shared by every client built in a run, so an expired token
+// is refreshed once. Linear rotates refresh tokens: a second refresh with the
+// old one would fail and log the user out.
+var activeOAuth *oauthSource
+
+// activeTransport builds the transport for the active credential.
+func activeTransport() (*authorizedTransport, error) {
+	cred, err := activeCredential()
+	if err != nil {
+		return nil, err
+	}
+	if !cred.isOAuth() {
+		return newAuthorizedTransport(cred)
+	}
+	if activeOAuth == nil {
+		activeOAuth, err = newOAuthSource(cred.secret, cred.store)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return &authorizedTransport{oauth: activeOAuth, base: withDebug(http.DefaultTransport)}, nil
+}
 
-	graphqlClient := graphql.NewClient("https://api.linear.app/graphql", httpClient)
+func runOAuthAuth(ctx context.Context) error {
+	cfg := loadOAuthConfig()
+	if authClientID != "" {
+		cfg.ClientID = authClientID
+	}
 
-	ctx := context.Background()
-	response, err := generated.Me(ctx, graphqlClient)
+	token, err := runOAuthLogin(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("OAuth login failed: %w", err)
+	}
+
+	fmt.Println("Verifying access token...")
+	token.Account, err = verifyAPIKey(ctx, "Bearer "+token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("access token verification failed: %w", err)
+	}
+
+	data, err := json.Marshal(token)
 	if err != nil {
 		return err
 	}
+	if authClientID != "" {
+		if err := saveConfig(map[string]any{"oauth.client_id": authClientID}); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+	return saveCredential(string(data), authStore, authTypeOAuth)
+}
+
+func verifyAPIKey(ctx context.Context, apiKey string) (string, error) {
+	graphqlClient := newLinearClient(&authorizedTransport{
+		apiKey: apiKey,
+		base:   withDebug(http.DefaultTransport),
+	})
+
+	response, err := generated.Me(ctx, graphqlClient)
+	if err != nil {
+		return "", err
+	}
 
 	fmt.Println("✓ API key verified successfully!")
 	fmt.Printf("\nAuthenticated as:\n")
 	fmt.Printf("  Name:  %s\n", response.Viewer.Name)
 	fmt.Printf("  Email: %s\n\n", response.Viewer.Email)
 
-	return nil
+	return response.Viewer.Id, nil
 }
 
-func writeAPIToken(apiKey string) error {
-	viper.Set("api_key", apiKey)
-
-	// Ensure the config directory exists
-	configDir, err := os.UserConfigDir()
+// saveCredential stores secret (an API key, or an OAuth token as JSON) in the
+// store named by ref and records the store and auth type in the config.
+func saveCredential(secret, ref, authType string) error {
+	var (
+		store secretStore
+		err   error
+	)
+	if ref == "" {
+		store, err = defaultSecretStore()
+	} else {
+		store, err = newSecretStore(ref)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return err
 	}
 
-	appConfigDir := configDir + "/quick-branch"
-	if err := os.MkdirAll(appConfigDir, 0o700); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	if authType == authTypeOAuth && store.String() == storeConfig {
+		return fmt.Errorf("OAuth tokens cannot be kept in the config file, choose another --store")
+	}
+	if err := store.Set(secret); err != nil {
+		return fmt.Errorf("failed to store credential in %s: %w", store, err)
 	}
 
-	configPath := appConfigDir + "/config.yaml"
-	viper.SetConfigFile(configPath)
-
-	err = viper.SafeWriteConfig()
+	err = saveConfig(map[string]any{
+		"credential_store": store.String(),
+		"auth_type":        authType,
+	})
 	if err != nil {
-		// If config doesn't exist, create it
-		err = viper.WriteConfig()
-		if err != nil {
-			return fmt.Errorf("failed to write config: %w", err)
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	// Cached issues may belong to a different account.
+	invalidateCache()
+	// Drop any plaintext key left over from older versions.
+	if store.String() != storeConfig {
+		if err := removeConfigKey("api_key"); err != nil {
+			return fmt.Errorf("failed to remove plaintext API key from config: %w", err)
 		}
 	}
-	fmt.Println("✓ API key saved to", configPath)
+	fmt.Printf("✓ Credentials saved (store: %s)\n", store)
 	return nil
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// openerWait is how long to wait for a browser command to fail before
+// taking it that the browser opened. Openers like xdg-open exit once the
+// browser has the URL; a browser run directly keeps going.
+var openerWait = 3 * time.Second
+
+// openBrowser tries to open target in the user's browser: $BROWSER when set
+// (a colon-separated list of commands, where %s stands for the URL),
+// otherwise the platform's opener.
+func openBrowser(target string) error {
+	if browsers := os.Getenv("BROWSER"); browsers != "" {
+		var err error
+		for _, browser := range strings.Split(browsers, ":") {
+			args := strings.Fields(browser)
+			if len(args) == 0 {
+				continue
+			}
+			if strings.Contains(browser, "%s") {
+				for i := range args {
+					args[i] = strings.ReplaceAll(args[i], "%s", target)
+				}
+			} else {
+				args = append(args, target)
+			}
+			if err = runOpener(exec.Command(args[0], args[1:]...)); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", target)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		c = exec.Command("xdg-open", target)
+	}
+	return runOpener(c)
+}
+
+// runOpener starts c and waits up to openerWait for it, so an opener that
+// can't find a browser is reported rather than taken for success.
+func runOpener(c *exec.Cmd) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Args[0], err)
+		}
+		return nil
+	case <-time.After(openerWait):
+		return nil
+	}
+}
+
+// remoteSession reports whether quick-branch runs over SSH, where a browser
+// would open on the remote machine, if at all.
+func remoteSession() bool {
+	return os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CLIENT") != ""
+}
+
+// openURL opens target in the browser. Over SSH (unless $BROWSER says how)
+// or when the browser command fails, it prints the URL instead and, in a
+// remote terminal, also copies it to the local clipboard with OSC 52.
+func openURL(target string) {
+	if !remoteSession() || os.Getenv("BROWSER") != "" {
+		if err := openBrowser(target); err == nil {
+			fmt.Printf("Opened %s\n", target)
+			return
+		}
+	}
+	fmt.Println(target)
+	if remoteSession() && writeOSC52(target) == nil {
+		fmt.Println("Sent to your terminal's clipboard (OSC 52)")
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// browserScript writes an executable shell script and returns its path.
+func browserScript(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenBrowser(t *testing.T) {
+	openerWait = 200 * time.Millisecond
+	t.Cleanup(func() { openerWait = 3 * time.Second })
+
+	failing := browserScript(t, "failing", "exit 3")
+	opener := browserScript(t, "opener", `echo "$1" > "$(dirname "$0")/opened"`)
+	browser := browserScript(t, "browser", "sleep 5")
+
+	tests := []struct {
+		name    string
+		browser string
+		wantErr bool
+	}{
+		{name: "opener exits cleanly", browser: opener},
+		{name: "browser keeps running", browser: browser},
+		{name: "opener fails", browser: failing, wantErr: true},
+		{name: "falls back to the next command", browser: failing + ":" + opener},
+		{name: "missing command", browser: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BROWSER", tt.browser)
+			err := openBrowser("https://linear.app/acme/issue/ENG-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("openBrowser() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err := os.ReadFile(filepath.Join(filepath.Dir(opener), "opened"))
+	if err != nil || string(got) != "https://linear.app/acme/issue/ENG-1\n" {
+		t.Errorf("opener got %q, %v; want the URL", got, err)
+	}
+}
@@ -0,0 +1,553 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/charmbracelet/huh"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	// Linear accepts at most this many ids per issueBatchUpdate.
+	batchSize = 50
+	// maxBulkIssues caps how many issues one bulk command may touch.
+	maxBulkIssues = 250
+)
+
+var (
+	fromList bool
+	dryRun   bool
+	yes      bool
+
+	bulkAssignee string
+	bulkState    string
+	bulkLabels   []string
+	bulkPriority string
+)
+
+var bulkCmd = &cobra.Command{
+	Use:   "bulk",
+	Short: "Change many issues at once",
+	Long: `bulk applies one change to several issues, given as identifiers or taken
+from your 'list' results with --from-list.
+
+A preview of every change is shown first and nothing is changed until you
+confirm (or pass --yes). Use --dry-run to only see the preview.`,
+}
+
+var bulkAssignCmd = &cobra.Command{
+	Use:   "assign [issueID...] --to <user>",
+	Short: "Assign issues to a user ('me' for yourself)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd.Context(), args, func(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+			user, err := resolveUser(ctx, client, bulkAssignee)
+			if err != nil {
+				return nil, err
+			}
+			changes := make([]bulkChange, len(issues))
+			for i, issue := range issues {
+				from := "unassigned"
+				if issue.Assignee != nil {
+					from = issue.Assignee.Name
+				}
+				changes[i] = bulkChange{
+					issue: issue,
+					input: generated.IssueUpdateInput{AssigneeId: &user.Id},
+					from:  from,
+					to:    user.Name,
+				}
+				if issue.Assignee != nil && issue.Assignee.Id == user.Id {
+					changes[i].skip = "already assigned to " + user.Name
+				} else if from == user.Name {
+					// A namesake; tell them apart by handle.
+					changes[i].to = fmt.Sprintf("%s (@%s)", user.Name, user.DisplayName)
+				}
+			}
+			return changes, nil
+		})
+	},
+}
+
+var bulkMoveCmd = &cobra.Command{
+	Use:   "move [issueID...] --state <state>",
+	Short: "Move issues to a workflow state",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd.Context(), args, func(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+			changes := make([]bulkChange, len(issues))
+			for i, issue := range issues {
+				// State ids differ per team; fetchTeamStates caches each team.
+				states, err := fetchTeamStates(ctx, client, issue.Team.Id)
+				if err != nil {
+					return nil, err
+				}
+				state, err := matchState(states, bulkState)
+				if err != nil {
+					return nil, fmt.Errorf("team %s: %w", issue.Team.Key, err)
+				}
+				changes[i] = bulkChange{
+					issue: issue,
+					input: generated.IssueUpdateInput{StateId: &state.Id},
+					from:  issue.State.Name,
+					to:    state.Name,
+				}
+				if issue.State.Id == state.Id {
+					changes[i].skip = "already in " + state.Name
+				}
+			}
+			return changes, nil
+		})
+	},
+}
+
+var bulkLabelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Change labels on many issues",
+}
+
+var bulkLabelAddCmd = &cobra.Command{
+	Use:   "add [issueID...] --label <label>",
+	Short: "Add labels to issues",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd.Context(), args, func(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+			labels, err := fetchLabels(ctx, client)
+			if err != nil {
+				return nil, err
+			}
+			changes := make([]bulkChange, len(issues))
+			for i, issue := range issues {
+				has := make(map[string]bool, len(issue.Labels.Nodes))
+				for _, l := range issue.Labels.Nodes {
+					has[l.Id] = true
+				}
+				// Only labels the issue lacks are added and previewed.
+				var ids, added, all []string
+				for _, name := range bulkLabels {
+					label, ok := findLabel(labels, name, issue.Team.Id)
+					if !ok {
+						return nil, fmt.Errorf("no label named %q for team %s", name, issue.Team.Key)
+					}
+					all = append(all, labelFieldsName(label))
+					if !has[label.Id] {
+						has[label.Id] = true
+						ids = append(ids, label.Id)
+						added = append(added, "+"+labelFieldsName(label))
+					}
+				}
+				changes[i] = bulkChange{
+					issue: issue,
+					input: generated.IssueUpdateInput{AddedLabelIds: ids},
+					to:    strings.Join(added, " "),
+				}
+				if len(ids) == 0 {
+					changes[i].skip = "already labelled " + strings.Join(all, ", ")
+				}
+			}
+			return changes, nil
+		})
+	},
+}
+
+var bulkPriorityCmd = &cobra.Command{
+	Use:   "priority [issueID...] --priority <priority>",
+	Short: "Set the priority of issues (none, urgent, high, medium, low or 0-4)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		priority, err := parsePriority(bulkPriority)
+		if err != nil {
+			return err
+		}
+		return runBulk(cmd.Context(), args, func(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+			changes := make([]bulkChange, len(issues))
+			for i, issue := range issues {
+				changes[i] = bulkChange{
+					issue: issue,
+					input: generated.IssueUpdateInput{Priority: &priority},
+					from:  priorityName(issue.Priority),
+					to:    priorityNames[priority],
+				}
+				if int(issue.Priority) == priority {
+					changes[i].skip = "priority is already " + priorityNames[priority]
+				}
+			}
+			return changes, nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bulkCmd)
+	bulkCmd.AddCommand(bulkAssignCmd, bulkMoveCmd, bulkLabelCmd, bulkPriorityCmd)
+	bulkLabelCmd.AddCommand(bulkLabelAddCmd)
+
+	addBulkFlags(bulkCmd.PersistentFlags())
+
+	bulkAssignCmd.Flags().StringVar(&bulkAssignee, "to", "", "User to assign: 'me', a name, display name, email or @handle")
+	bulkAssignCmd.MarkFlagRequired("to")
+	bulkAssignCmd.RegisterFlagCompletionFunc("to", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		ctx, client, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		users, err := fetchUsers(ctx, client)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return completeUsers(users, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	bulkMoveCmd.Flags().StringVar(&bulkState, "state", "", "Name of the workflow state to move issues to")
+	bulkMoveCmd.MarkFlagRequired("state")
+	bulkLabelAddCmd.Flags().StringSliceVarP(&bulkLabels, "label", "l", nil, "Label to add (repeatable)")
+	bulkLabelAddCmd.MarkFlagRequired("label")
+	bulkPriorityCmd.Flags().StringVarP(&bulkPriority, "priority", "p", "", "Priority: none, urgent, high, medium, low or 0-4")
+	bulkPriorityCmd.MarkFlagRequired("priority")
+}
+
+// addBulkFlags registers the flags shared by every command that changes
+// several issues at once.
+func addBulkFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&fromList, "from-list", false, "Also include every issue 'quick-branch list' shows")
+	flags.BoolVarP(&dryRun, "dry-run", "n", false, "Only preview the changes")
+	flags.BoolVarP(&yes, "yes", "y", false, "Apply the changes without asking for confirmation")
+}
+
+// bulkChange is the update planned for one issue, with a before/after
+// description for the preview.
+type bulkChange struct {
+	issue    generated.BulkIssueFields
+	input    generated.IssueUpdateInput
+	from, to string
+	// skip says why the issue needs no change, decided by comparing IDs
+	// since names need not be unique. Skipped issues are left alone.
+	skip string
+	// blockedBy lists unfinished blockers worth flagging in the preview.
+	blockedBy []string
+}
+
+func (c bulkChange) String() string {
+	if c.from == "" {
+		return c.to
+	}
+	return c.from + " → " + c.to
+}
+
+// runBulk resolves the issues named by args (and --from-list), lets plan
+// decide the update for each one, previews the result and, once confirmed,
+// applies it with as few issueBatchUpdate calls as possible.
+func runBulk(ctx context.Context, args []string, plan func(context.Context, graphql.Client, []generated.BulkIssueFields) ([]bulkChange, error)) error {
+	client, err := newGraphQLClient()
+	if err != nil {
+		return err
+	}
+	issues, err := resolveBulkIssues(ctx, client, args)
+	if err != nil {
+		return err
+	}
+	planned, err := plan(ctx, client, issues)
+	if err != nil {
+		return err
+	}
+
+	var changes []bulkChange
+	for _, c := range planned {
+		if c.skip != "" {
+			fmt.Printf("Skipping %s: %s\n", c.issue.Identifier, c.skip)
+			continue
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing to change.")
+		return nil
+	}
+
+	printBulkPreview(changes)
+	if dryRun {
+		fmt.Println("Dry run: no issues were changed.")
+		return nil
+	}
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Apply these changes to %s?", plural(len(changes), "issue")))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+	return applyBulk(ctx, client, changes)
+}
+
+// resolveBulkIssues fetches the issues named by args, plus the list results
+// with --from-list, in one query. The list filter is sent as is rather than
+// reusing list's own query, which stops at Linear's default page size.
+// Duplicates are dropped and args keep their order.
+func resolveBulkIssues(ctx context.Context, client graphql.Client, args []string) ([]generated.BulkIssueFields, error) {
+	var filters []generated.IssueFilter
+	for _, arg := range args {
+		filters = append(filters, issueRefFilter(arg))
+	}
+	if fromList {
+		filter, err := listIssueFilter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, *filter)
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("pass one or more issue identifiers, or --from-list")
+	}
+
+	first := maxBulkIssues + 1
+	resp, err := generated.BulkIssues(ctx, client, generated.IssueFilter{Or: filters}, &first)
+	if err != nil {
+		return nil, err
+	}
+	nodes := resp.Issues.Nodes
+	if len(nodes) > maxBulkIssues {
+		return nil, fmt.Errorf("more than %d issues selected; narrow the selection", maxBulkIssues)
+	}
+	if len(nodes) == 0 && len(args) == 0 {
+		return nil, fmt.Errorf("'quick-branch list' shows no issues")
+	}
+
+	found := make(map[string]generated.BulkIssueFields, len(nodes))
+	for _, n := range nodes {
+		found[strings.ToUpper(n.Identifier)] = n.BulkIssueFields
+		found[n.Id] = n.BulkIssueFields
+	}
+	seen := make(map[string]bool)
+	var issues []generated.BulkIssueFields
+	add := func(issue generated.BulkIssueFields) {
+		if !seen[issue.Id] {
+			seen[issue.Id] = true
+			issues = append(issues, issue)
+		}
+	}
+	for _, arg := range args {
+		issue, ok := found[strings.ToUpper(arg)]
+		if !ok {
+			issue, ok = found[arg]
+		}
+		if !ok {
+			return nil, fmt.Errorf("issue %s not found in your workspace", strings.ToUpper(arg))
+		}
+		add(issue)
+	}
+	for _, n := range nodes {
+		add(n.BulkIssueFields)
+	}
+	return issues, nil
+}
+
+// issueRefFilter matches an issue by identifier (ENG-123) or by id.
+func issueRefFilter(ref string) generated.IssueFilter {
+	m := issueKeyPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return generated.IssueFilter{Id: &generated.IDComparator{Eq: &ref}}
+	}
+	number, _ := strconv.ParseFloat(ref[len(m[1])+1:], 64)
+	return generated.IssueFilter{
+		Team:   &generated.TeamFilter{Key: &generated.StringComparator{EqIgnoreCase: &m[1]}},
+		Number: &generated.NumberComparator{Eq: &number},
+	}
+}
+
+func printBulkPreview(changes []bulkChange) {
+	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || termWidth == 0 {
+		termWidth = 100
+	}
+	t := compactTable("ID", "TITLE", "CHANGE")
+	for _, c := range changes {
+		id := c.issue.Identifier
+		if len(c.blockedBy) > 0 {
+			id += " ⚠"
+		}
+		t.Row(id, truncate(c.issue.Title, max(termWidth/2, 10)), c.String())
+	}
+	fmt.Println(t)
+	for _, c := range changes {
+		if len(c.blockedBy) > 0 {
+			fmt.Printf("⚠ %s is blocked by %s\n", c.issue.Identifier, strings.Join(c.blockedBy, ", "))
+		}
+	}
+}
+
+// applyBulk sends changes with identical input together, batchSize issues
+// per issueBatchUpdate.
+func applyBulk(ctx context.Context, client graphql.Client, changes []bulkChange) error {
+	var order []string
+	groups := make(map[string][]bulkChange)
+	for _, c := range changes {
+		key, err := json.Marshal(c.input)
+		if err != nil {
+			return err
+		}
+		if _, ok := groups[string(key)]; !ok {
+			order = append(order, string(key))
+		}
+		groups[string(key)] = append(groups[string(key)], c)
+	}
+
+	updated := 0
+	for _, key := range order {
+		group := groups[key]
+		for start := 0; start < len(group); start += batchSize {
+			batch := group[start:min(start+batchSize, len(group))]
+			ids := make([]string, len(batch))
+			for i, c := range batch {
+				ids[i] = c.issue.Id
+			}
+			resp, err := generated.IssueBatchUpdate(ctx, client, ids, batch[0].input)
+			for _, c := range batch {
+				invalidateIssueCache(c.issue.Identifier, c.issue.Id)
+			}
+			// Cycle overviews list their issues and states.
+			invalidateCache("cycle-")
+			if err != nil {
+				return fmt.Errorf("updated %s, then failed: %w", plural(updated, "issue"), err)
+			}
+			if !resp.IssueBatchUpdate.Success {
+				return fmt.Errorf("updated %s, then Linear rejected a batch", plural(updated, "issue"))
+			}
+			updated += len(batch)
+		}
+	}
+	fmt.Printf("Success! Updated %s\n", plural(updated, "issue"))
+	return nil
+}
+
+// confirm asks a yes/no question, refusing to guess when there is no
+// terminal to ask on.
+func confirm(question string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("not a terminal; pass --yes to apply without confirmation")
+	}
+	var ok bool
+	err := huh.NewConfirm().Title(question).Value(&ok).Run()
+	return ok, err
+}
+
+// findState returns the workflow state called name (case-insensitive).
+func findState(states []generated.WorkflowStateFields, name string) (generated.WorkflowStateFields, bool) {
+	for _, s := range states {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return generated.WorkflowStateFields{}, false
+}
+
+// fetchUsers returns the workspace's users, from the cache when fresh.
+func fetchUsers(ctx context.Context, client graphql.Client) ([]generated.UserFields, error) {
+	users, _, err := cached("users", func() ([]generated.UserFields, error) {
+		return fetchPages(func(after *string) ([]generated.UserFields, generated.PageInfoFields, error) {
+			response, err := generated.Users(ctx, client, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, err
+			}
+			users := make([]generated.UserFields, len(response.Users.Nodes))
+			for i, n := range response.Users.Nodes {
+				users[i] = n.UserFields
+			}
+			return users, response.Users.PageInfo.PageInfoFields, nil
+		})
+	})
+	return users, err
+}
+
+// resolveUser finds the user query names: "me", or the workspace member
+// that best matches it (see matchUser).
+func resolveUser(ctx context.Context, client graphql.Client, query string) (generated.UserFields, error) {
+	if strings.EqualFold(query, "me") {
+		viewer, err := fetchViewer(ctx, client)
+		if err != nil {
+			return generated.UserFields{}, err
+		}
+		return generated.UserFields{Id: viewer.Id, Name: viewer.Name, Email: viewer.Email, Active: true}, nil
+	}
+	users, err := fetchUsers(ctx, client)
+	if err != nil {
+		return generated.UserFields{}, err
+	}
+	return matchUser(users, query)
+}
+
+// fetchLabels returns the workspace's and teams' issue labels, from the
+// cache when fresh.
+func fetchLabels(ctx context.Context, client graphql.Client) ([]generated.LabelFields, error) {
+	labels, _, err := cached("labels", func() ([]generated.LabelFields, error) {
+		return fetchPages(func(after *string) ([]generated.LabelFields, generated.PageInfoFields, error) {
+			response, err := generated.Labels(ctx, client, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, err
+			}
+			labels := make([]generated.LabelFields, len(response.IssueLabels.Nodes))
+			for i, n := range response.IssueLabels.Nodes {
+				labels[i] = n.LabelFields
+			}
+			return labels, response.IssueLabels.PageInfo.PageInfoFields, nil
+		})
+	})
+	return labels, err
+}
+
+// findLabel returns the label called name (or "Group/name") that can be used
+// on teamID's issues, preferring a team label over a workspace label of the
+// same name. Label groups themselves can't be applied and are skipped.
+func findLabel(labels []generated.LabelFields, name, teamID string) (generated.LabelFields, bool) {
+	var workspace *generated.LabelFields
+	for i, l := range labels {
+		if l.IsGroup || !(strings.EqualFold(l.Name, name) || strings.EqualFold(labelFieldsName(l), name)) {
+			continue
+		}
+		if l.Team == nil {
+			workspace = &labels[i]
+		} else if l.Team.Id == teamID {
+			return l, true
+		}
+	}
+	if workspace != nil {
+		return *workspace, true
+	}
+	return generated.LabelFields{}, false
+}
+
+// priorityNames are Linear's priority levels, indexed by value.
+var priorityNames = []string{"No priority", "Urgent", "High", "Medium", "Low"}
+
+func priorityName(priority float64) string {
+	if p := int(priority); p >= 0 && p < len(priorityNames) {
+		return priorityNames[p]
+	}
+	return strconv.FormatFloat(priority, 'f', 0, 64)
+}
+
+// parsePriority accepts a priority level's number or name.
+func parsePriority(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(priorityNames) {
+		return n, nil
+	}
+	switch strings.ToLower(s) {
+	case "none", "no priority":
+		return 0, nil
+	case "urgent":
+		return 1, nil
+	case "high":
+		return 2, nil
+	case "medium", "normal":
+		return 3, nil
+	case "low":
+		return 4, nil
+	}
+	return 0, fmt.Errorf("unknown priority %q; use none, urgent, high, medium, low or 0-4", s)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+func TestFindLabel(t *testing.T) {
+	labels := []generated.LabelFields{
+		{Id: "ws-bug", Name: "Bug"},
+		{Id: "eng-bug", Name: "Bug", Team: &generated.LabelFieldsTeam{Id: "eng"}},
+		{Id: "ops-bug", Name: "Bug", Team: &generated.LabelFieldsTeam{Id: "ops"}},
+		{Id: "area", Name: "Area", IsGroup: true},
+		{Id: "area-api", Name: "API", Parent: &generated.LabelFieldsParentIssueLabel{Name: "Area"}},
+	}
+	tests := []struct {
+		name, query, team string
+		want              string
+	}{
+		{name: "team label wins", query: "bug", team: "eng", want: "eng-bug"},
+		{name: "workspace label for other teams", query: "Bug", team: "design", want: "ws-bug"},
+		{name: "by name", query: "api", team: "eng", want: "area-api"},
+		{name: "by group and name", query: "Area/API", team: "eng", want: "area-api"},
+		{name: "groups can't be applied", query: "Area", team: "eng"},
+		{name: "unknown", query: "Feature", team: "eng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findLabel(labels, tt.query, tt.team)
+			if ok != (tt.want != "") || got.Id != tt.want {
+				t.Errorf("findLabel(%q, %q) = %q, %v; want %q", tt.query, tt.team, got.Id, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "4", want: 4},
+		{in: "none", want: 0},
+		{in: "No Priority", want: 0},
+		{in: "Urgent", want: 1},
+		{in: "high", want: 2},
+		{in: "normal", want: 3},
+		{in: "medium", want: 3},
+		{in: "LOW", want: 4},
+		{in: "5", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "asap", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePriority(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePriority(%q) = %d, %v; want %d (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Cached entities and how long each stays fresh unless cache.ttl.<entity>
+// says otherwise.
+var defaultCacheTTLs = map[string]time.Duration{
+	"issues":   5 * time.Minute,
+	"issue":    5 * time.Minute,
+	"teams":    24 * time.Hour,
+	"states":   24 * time.Hour,
+	"viewer":   24 * time.Hour,
+	"users":    24 * time.Hour,
+	"labels":   24 * time.Hour,
+	"cycles":   time.Hour,
+	"cycle":    5 * time.Minute,
+	"projects": time.Hour,
+	"project":  5 * time.Minute,
+	"inbox":    time.Minute,
+}
+
+var (
+	offline bool
+	refresh bool
+	// noCache bypasses the cache entirely, reading and writing nothing.
+	noCache bool
+)
+
+type cacheEntry[T any] struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Data      T         `json:"data"`
+}
+
+func cacheTTL(entity string) time.Duration {
+	if ttl, err := time.ParseDuration(viper.GetString("cache.ttl." + entity)); err == nil {
+		return ttl
+	}
+	return defaultCacheTTLs[entity]
+}
+
+func cacheRoot() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(dir, "quick-branch"), nil
+}
+
+// cacheDir is the cache directory of the account in use, so switching API
+// keys or workspaces never shows another account's data.
+func cacheDir() (string, error) {
+	root, err := cacheRoot()
+	if err != nil {
+		return "", err
+	}
+	cred, err := activeCredential()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, cred.cacheScope()), nil
+}
+
+var unsafeCacheChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func cachePath(key string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, unsafeCacheChars.ReplaceAllString(key, "_")+".json"), nil
+}
+
+func readCache[T any](key string) (*cacheEntry[T], error) {
+	path, err := cachePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry[T]
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func writeCache[T any](key string, value T) error {
+	path, err := cachePath(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry[T]{FetchedAt: time.Now(), Data: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// cached returns the value stored under key (a name prefixed with its entity,
+// e.g. "issue-ENG-123") while it is fresh, and otherwise calls fetch and
+// stores the result. It also reports when the returned value was fetched.
+//
+// With --offline only the cache is consulted, whatever its age; with
+// --refresh the cache is skipped. If fetch fails because the network is
+// unreachable, stale data is returned with a warning.
+func cached[T any](key string, fetch func() (T, error)) (T, time.Time, error) {
+	if noCache {
+		value, err := fetch()
+		return value, time.Now(), err
+	}
+	entity, id, _ := strings.Cut(key, "-")
+	entry, cacheErr := readCache[T](key)
+
+	if offline {
+		if cacheErr != nil {
+			what := entity
+			if entity == "issue" {
+				what += " " + id
+			}
+			var zero T
+			return zero, time.Time{}, fmt.Errorf("no cached %s yet; run once without --offline", what)
+		}
+		return entry.Data, entry.FetchedAt, nil
+	}
+	if !refresh && cacheErr == nil && time.Since(entry.FetchedAt) < cacheTTL(entity) {
+		return entry.Data, entry.FetchedAt, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		var urlErr *neturl.Error
+		if cacheErr == nil && errors.As(err, &urlErr) {
+			fmt.Fprintf(os.Stderr, "warning: %v; showing data %s\n", err, cachedAgo(entry.FetchedAt))
+			return entry.Data, entry.FetchedAt, nil
+		}
+		return value, time.Time{}, err
+	}
+	if err := writeCache(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to update cache: %v\n", err)
+	}
+	return value, time.Now(), nil
+}
+
+// invalidateCache removes cached entries whose key starts with any of
+// prefixes, or everything for every account when none are given.
+func invalidateCache(prefixes ...string) {
+	if noCache {
+		return
+	}
+	if len(prefixes) == 0 {
+		if root, err := cacheRoot(); err == nil {
+			os.RemoveAll(root)
+		}
+		return
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		match := false
+		for _, p := range prefixes {
+			if strings.HasPrefix(name, unsafeCacheChars.ReplaceAllString(p, "_")) {
+				match = true
+				break
+			}
+		}
+		if match {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
+
+// invalidateIssueCache drops everything that may show an issue after it has
+// been changed. Issues are cached under whichever reference was typed, so
+// pass every one known, typically the identifier and the id the mutation
+// returned; empty references are ignored.
+func invalidateIssueCache(refs ...string) {
+	prefixes := []string{"issues-"}
+	for _, ref := range refs {
+		if ref != "" {
+			prefixes = append(prefixes, "issue-"+strings.ToUpper(ref)+".")
+		}
+	}
+	invalidateCache(prefixes...)
+}
+
+// cachedAgo describes the age of data fetched at t, e.g. "cached 3 minutes
+// ago".
+func cachedAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "cached just now"
+	case d < time.Hour:
+		return "cached " + plural(int(d.Minutes()), "minute") + " ago"
+	case d < 48*time.Hour:
+		return "cached " + plural(int(d.Hours()), "hour") + " ago"
+	default:
+		return "cached " + plural(int(d.Hours()/24), "day") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+// isolateCache points the cache at a temporary directory for an API key
+// account.
+func isolateCache(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	activeCred = &credential{secret: "lin_api_test", source: sourceEnv}
+	t.Cleanup(func() { activeCred = nil })
+}
+
+func TestInvalidateIssueCache(t *testing.T) {
+	isolateCache(t)
+	const uuid = "9f3c2a4e-1b2d-4c5e-8f70-123456789abc"
+	// Issue keys are upper-cased like fetchIssue and fetchTree do.
+	upper := strings.ToUpper(uuid)
+	keys := []string{
+		"issue-ENG-1", "issue-ENG-1.tree", "issue-" + upper, "issue-" + upper + ".tree",
+		"issues-1a2b3c", "issue-ENG-10", "issue-ENG-2", "viewer",
+	}
+	for _, key := range keys {
+		if err := writeCache(key, "cached"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Changed by identifier; the mutation returned its id.
+	invalidateIssueCache("eng-1", uuid, "")
+
+	for _, key := range keys {
+		_, err := readCache[string](key)
+		kept := err == nil
+		wantKept := key == "issue-ENG-10" || key == "issue-ENG-2" || key == "viewer"
+		if kept != wantKept {
+			t.Errorf("%s kept = %v, want %v", key, kept, wantKept)
+		}
+	}
+}
+
+// hangingTransport never answers, so every request runs into --timeout.
+type hangingTransport struct{}
+
+func (hangingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestCachedFallsBackOnTimeout(t *testing.T) {
+	isolateCache(t)
+	requestTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { requestTimeout = 0 })
+
+	stale := generated.MeViewerUser{Id: "u1", Name: "Jane Doe"}
+	if err := writeCache("viewer", stale); err != nil {
+		t.Fatal(err)
+	}
+	// Age the entry past its TTL so it is refetched.
+	path, _ := cachePath("viewer")
+	old := time.Now().Add(-48 * time.Hour)
+	entry, _ := json.Marshal(cacheEntry[generated.MeViewerUser]{FetchedAt: old, Data: stale})
+	if err := os.WriteFile(path, entry, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newLinearClient(&authorizedTransport{apiKey: "lin_api_test", base: hangingTransport{}})
+	viewer, fetchedAt, err := cached("viewer", func() (generated.MeViewerUser, error) {
+		response, err := generated.Me(context.Background(), client)
+		if err != nil {
+			return generated.MeViewerUser{}, err
+		}
+		return response.Viewer, nil
+	})
+	if err != nil {
+		t.Fatalf("cached returned %v, want the stale viewer", err)
+	}
+	if viewer.Id != "u1" || !fetchedAt.Equal(old) {
+		t.Errorf("got %+v fetched at %s, want the stale entry", viewer, fetchedAt)
+	}
+}
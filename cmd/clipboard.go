@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"golang.org/x/term"
+)
+
+// copyMethod says where copyText put the text.
+type copyMethod int
+
+const (
+	copiedToClipboard copyMethod = iota // the system clipboard
+	copiedWithOSC52                     // maybe the terminal, via OSC 52; also printed
+	copiedToStdout                      // nowhere; the text was printed
+)
+
+// copyText puts text on the system clipboard. Without one (headless or SSH
+// sessions) it asks the terminal to do it with OSC 52, and without a
+// terminal it prints the text so it can be piped elsewhere.
+//
+// Terminals don't acknowledge OSC 52 and many ignore it, so the text is
+// printed then too: to stdout, or to stderr when stdout is redirected and
+// the text would otherwise end up only in a file or pipe.
+func copyText(text string) copyMethod {
+	if err := clipboard.WriteAll(text); err == nil {
+		return copiedToClipboard
+	}
+	if err := writeOSC52(text); err == nil {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Println(text)
+		} else {
+			fmt.Fprintln(os.Stderr, text)
+		}
+		return copiedWithOSC52
+	}
+	fmt.Println(text)
+	return copiedToStdout
+}
+
+// copyAndReport copies text and tells the user how; what names it in the
+// message, e.g. "branch name".
+func copyAndReport(what, text string) {
+	switch copyText(text) {
+	case copiedToClipboard:
+		fmt.Printf("Copied %s to clipboard\n", what)
+	case copiedWithOSC52:
+		fmt.Printf("Asked your terminal to copy %s (OSC 52); if it didn't, copy it from above\n", what)
+	case copiedToStdout:
+		fmt.Fprintf(os.Stderr, "No clipboard available; printed %s instead\n", what)
+	}
+}
+
+// writeOSC52 asks the terminal to put text on the clipboard of the machine
+// it runs on, which works across SSH in most modern terminals. Inside tmux
+// the sequence is wrapped so tmux passes it through. It fails when there is
+// no terminal to write to.
+func writeOSC52(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	// Write to the terminal itself so piping stdout doesn't swallow it.
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(seq)
+	return err
+}
@@ -0,0 +1,510 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// Kinds of value a config key holds, used to parse 'config set' arguments.
+const (
+	kindString   = "string"
+	kindInt      = "int"
+	kindList     = "list"
+	kindDuration = "duration"
+)
+
+// configKey describes a setting quick-branch understands. userOnly settings
+// are ignored in per-repository config files.
+type configKey struct {
+	name     string
+	kind     string
+	usage    string
+	secret   bool
+	userOnly bool
+}
+
+var configKeys = []configKey{
+	{name: "api_key", kind: kindString, usage: "Linear API key in plain text (prefer 'quick-branch auth')", secret: true, userOnly: true},
+	{name: "credential_store", kind: kindString, usage: "Where the API key is kept: keyring, file, config or exec:<command>", userOnly: true},
+	{name: "auth_type", kind: kindString, usage: "api_key or oauth", userOnly: true},
+	{name: "oauth.client_id", kind: kindString, usage: "OAuth application client ID", userOnly: true},
+	{name: "oauth.client_secret", kind: kindString, usage: "OAuth application client secret (confidential clients only)", secret: true, userOnly: true},
+	{name: "oauth.authorize_url", kind: kindString, usage: "OAuth authorize endpoint", userOnly: true},
+	{name: "oauth.token_url", kind: kindString, usage: "OAuth token endpoint", userOnly: true},
+	{name: "oauth.redirect_port", kind: kindInt, usage: "Loopback port for the OAuth redirect", userOnly: true},
+	{name: "oauth.scopes", kind: kindString, usage: "Comma-separated OAuth scopes", userOnly: true},
+	{name: "list.team_id", kind: kindString, usage: "Team whose issues 'list' shows"},
+	{name: "list.team_name", kind: kindString, usage: "Display name of list.team_id"},
+	{name: "list.assignee_filter", kind: kindString, usage: "me, unassigned or all"},
+	{name: "list.state_ids", kind: kindList, usage: "Workflow state IDs 'list' includes"},
+	{name: "states.in_progress", kind: kindString, usage: "Workflow state 'start --status' moves issues to (default \"In Progress\")"},
+	{name: "branch.base", kind: kindString, usage: "Branch new issue branches are created from (default: current HEAD)"},
+	{name: "cache.ttl.issues", kind: kindDuration, usage: "How long cached 'list' results stay fresh (default 5m)"},
+	{name: "cache.ttl.issue", kind: kindDuration, usage: "How long a cached issue stays fresh (default 5m)"},
+	{name: "cache.ttl.teams", kind: kindDuration, usage: "How long cached teams stay fresh (default 24h)"},
+	{name: "cache.ttl.states", kind: kindDuration, usage: "How long cached workflow states stay fresh (default 24h)"},
+	{name: "cache.ttl.viewer", kind: kindDuration, usage: "How long your cached user stays fresh (default 24h)"},
+	{name: "cache.ttl.users", kind: kindDuration, usage: "How long the cached workspace members stay fresh (default 24h)"},
+	{name: "cache.ttl.labels", kind: kindDuration, usage: "How long cached issue labels stay fresh (default 24h)"},
+	{name: "cache.ttl.cycles", kind: kindDuration, usage: "How long a team's cached cycle list stays fresh (default 1h)"},
+	{name: "cache.ttl.cycle", kind: kindDuration, usage: "How long a cached cycle overview stays fresh (default 5m)"},
+	{name: "cache.ttl.projects", kind: kindDuration, usage: "How long the cached project list stays fresh (default 1h)"},
+	{name: "cache.ttl.project", kind: kindDuration, usage: "How long a cached project overview stays fresh (default 5m)"},
+	{name: "cache.ttl.inbox", kind: kindDuration, usage: "How long cached notifications and the unread count stay fresh (default 1m)"},
+}
+
+func lookupConfigKey(name string) (configKey, bool) {
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, true
+		}
+	}
+	return configKey{}, false
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and change quick-branch settings",
+	Long: `Inspect and change quick-branch settings without the setup wizard.
+
+Settings are read from the user config file, then from a ` + repoConfigName + ` at
+the root of the current git repository (so each repo can pick its own team,
+states and base branch), then from the environment. Every setting can be
+overridden with an environment variable named after the key with a
+QUICK_BRANCH_ prefix, e.g. list.team_id -> QUICK_BRANCH_LIST_TEAM_ID.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the effective value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !viper.IsSet(args[0]) {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		fmt.Println(formatConfigValue(viper.Get(args[0])))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value...>",
+	Short: "Change a setting in the config file",
+	Long: `Change a setting in the config file. List settings take one or more values:
+
+  quick-branch config set list.assignee_filter me
+  quick-branch config set list.state_ids <state id> <state id>`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, ok := lookupConfigKey(args[0])
+		if !ok {
+			return fmt.Errorf("unknown setting %q. Run 'quick-branch config list --all' to see the available settings", args[0])
+		}
+
+		var value any
+		switch key.kind {
+		case kindList:
+			value = args[1:]
+		case kindInt:
+			if len(args) != 2 {
+				return fmt.Errorf("%s takes a single value", key.name)
+			}
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("%s must be a number", key.name)
+			}
+			value = n
+		case kindDuration:
+			if len(args) != 2 {
+				return fmt.Errorf("%s takes a single value", key.name)
+			}
+			if _, err := time.ParseDuration(args[1]); err != nil {
+				return fmt.Errorf("%s must be a duration such as 10m or 2h", key.name)
+			}
+			value = args[1]
+		default:
+			if len(args) != 2 {
+				return fmt.Errorf("%s takes a single value", key.name)
+			}
+			value = args[1]
+		}
+
+		path, err := configFilePath()
+		if configRepo {
+			path, err = repoConfigTarget()
+			if err == nil && key.userOnly {
+				err = fmt.Errorf("%s can only be set in your user config", key.name)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := saveConfigTo(path, map[string]any{key.name: value}); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✓ Set %s in %s\n", key.name, path)
+		return nil
+	},
+}
+
+var (
+	configListAll bool
+	configRepo    bool
+)
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List settings and where each value comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := viper.AllKeys()
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !viper.IsSet(name) {
+				if configListAll {
+					fmt.Printf("%s =\n", name)
+				}
+				continue
+			}
+			key, _ := lookupConfigKey(name)
+			value := formatConfigValue(viper.Get(name))
+			if key.secret && value != "" {
+				value = redact(value)
+			}
+			fmt.Printf("%s = %s (%s)\n", name, value, configSource(name))
+		}
+		return nil
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if configRepo {
+			path, err = repoConfigTarget()
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if err := os.WriteFile(path, nil, 0o600); err != nil {
+				return err
+			}
+		}
+		return runEditor(path)
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if configRepo {
+			path, err = repoConfigTarget()
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configPathCmd)
+	configListCmd.Flags().BoolVarP(&configListAll, "all", "a", false, "Include settings that are not set")
+	for _, c := range []*cobra.Command{configSetCmd, configEditCmd, configPathCmd} {
+		c.Flags().BoolVar(&configRepo, "repo", false, "Use the repository's "+repoConfigName+" instead of the user config")
+	}
+}
+
+func repoConfigTarget() (string, error) {
+	path := repoConfigPath()
+	if path == "" {
+		return "", fmt.Errorf("not inside a git repository")
+	}
+	return path, nil
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(configKeys))
+	for _, k := range configKeys {
+		names = append(names, k.name+"\t"+k.usage)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// configSource reports where the effective value of key comes from.
+func configSource(key string) string {
+	env := "QUICK_BRANCH_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if _, ok := os.LookupEnv(env); ok {
+		return "env " + env
+	}
+	if repoSettings != nil && hasNested(repoSettings, key) {
+		return "repo " + repoConfigFile
+	}
+	return "config"
+}
+
+func formatConfigValue(v any) string {
+	switch v := v.(type) {
+	case []any:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = fmt.Sprint(p)
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+func redact(s string) string {
+	if len(s) <= 8 {
+		return "********"
+	}
+	return s[:4] + "…" + s[len(s)-4:]
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi (or notepad
+// on Windows).
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	args := strings.Fields(editor)
+	c := exec.Command(args[0], append(args[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// configFilePath returns the --config file, or config.yaml in the platform
+// config directory.
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "quick-branch", "config.yaml"), nil
+}
+
+// readConfigFile returns the raw contents of the config file, so that writes
+// only ever persist what was already in the file plus the change being made
+// — never values that came from the environment.
+func readConfigFile(path string) (map[string]any, error) {
+	settings := map[string]any{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	return settings, nil
+}
+
+func writeConfigFile(path string, settings map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o600)
+}
+
+// saveConfig writes the given (dot-separated) keys to the user config file
+// and reloads it.
+func saveConfig(values map[string]any) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	return saveConfigTo(path, values)
+}
+
+func saveConfigTo(path string, values map[string]any) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		setNested(settings, key, value)
+	}
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+	return loadConfigFile()
+}
+
+// removeConfigKey deletes a (dot-separated) key from the user config file.
+// Viper has no way to unset a value, so the file is rewritten without it and
+// reloaded.
+func removeConfigKey(key string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if !deleteNested(settings, key) {
+		return nil
+	}
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+	return loadConfigFile()
+}
+
+func setNested(settings map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	m := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// deleteNested removes key from settings and reports whether it was there.
+func deleteNested(settings map[string]any, key string) bool {
+	parts := strings.Split(key, ".")
+	m := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		m = next
+	}
+	if _, ok := m[parts[len(parts)-1]]; !ok {
+		return false
+	}
+	delete(m, parts[len(parts)-1])
+	return true
+}
+
+func hasNested(settings map[string]any, key string) bool {
+	parts := strings.Split(key, ".")
+	m := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		m = next
+	}
+	_, ok := m[parts[len(parts)-1]]
+	return ok
+}
+
+// repoConfigName is the per-repository config file looked up at the root of
+// the current git repository.
+const repoConfigName = ".quick-branch.yaml"
+
+var (
+	// repoConfigFile and repoSettings describe the repository config merged
+	// by the last loadConfigFile, if any.
+	repoConfigFile string
+	repoSettings   map[string]any
+	// ignoredRepoKeys keeps the warning below to once per run.
+	ignoredRepoKeys = map[string]bool{}
+)
+
+// repoConfigPath returns where the current git repository's config file
+// lives, or "" outside a repository.
+func repoConfigPath() string {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), repoConfigName)
+}
+
+// mergeRepoConfig merges the repository config over the user config. Keys
+// that control credentials are ignored so that a cloned repository can't
+// redirect your API key or run commands through a credential helper.
+func mergeRepoConfig() error {
+	repoConfigFile, repoSettings = "", nil
+
+	path := repoConfigPath()
+	if path == "" {
+		return nil
+	}
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	for _, key := range configKeys {
+		if key.userOnly && deleteNested(settings, key.name) && !ignoredRepoKeys[key.name] {
+			ignoredRepoKeys[key.name] = true
+			fmt.Fprintf(os.Stderr, "warning: ignoring %s in %s; it can only be set in your user config\n", key.name, path)
+		}
+	}
+
+	repoConfigFile, repoSettings = path, settings
+	return viper.MergeConfigMap(settings)
+}
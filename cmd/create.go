@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createParent      string
+	createDescription string
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create <title>...",
+	Short: "Create an issue, optionally as a sub-issue",
+	Long: `create adds an issue to the team 'list' is configured for. With --parent
+it becomes a sub-issue of that issue, in the parent's team.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+
+		title := strings.Join(args, " ")
+		input := generated.IssueCreateInput{Title: &title}
+		if createDescription != "" {
+			input.Description = &createDescription
+		}
+		// The parent lists its children, so its cache goes stale too.
+		var parentRefs []string
+		if createParent != "" {
+			parent, err := fetchIssue(ctx, createParent)
+			if err != nil {
+				return err
+			}
+			input.ParentId = &parent.Id
+			input.TeamId = parent.Team.Id
+			parentRefs = []string{createParent, parent.Id, parent.Identifier}
+		} else {
+			input.TeamId, err = listTeamID()
+			if err != nil {
+				return err
+			}
+		}
+
+		resp, err := generated.IssueCreate(ctx, client, input)
+		if err != nil {
+			return err
+		}
+		if parentRefs != nil {
+			invalidateIssueCache(parentRefs...)
+		} else {
+			invalidateCache("issues-")
+		}
+		issue := resp.IssueCreate.Issue
+		if !resp.IssueCreate.Success || issue == nil {
+			return fmt.Errorf("issue %q could not be created", title)
+		}
+		fmt.Printf("Success! Created %s %s\n", issue.Identifier, issue.Title)
+		fmt.Println(issue.Url)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createCmd)
+
+	createCmd.Flags().StringVar(&createParent, "parent", "", "Create the issue as a sub-issue of this issue")
+	createCmd.Flags().StringVarP(&createDescription, "description", "d", "", "Markdown description of the issue")
+}
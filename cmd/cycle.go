@@ -0,0 +1,527 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+const burndownHeight = 8
+
+var (
+	headingStyle = lipgloss.NewStyle().Bold(true)
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#54546D"))
+	barStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#7E9CD8"))
+)
+
+var cycleCmd = &cobra.Command{
+	Use:   "cycle",
+	Short: "Show your team's current cycle",
+	Long: `cycle shows the active cycle of the team chosen in 'quick-branch list setup':
+its dates, progress and scope, its issues grouped by state and a burndown
+chart of the remaining work.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showCycle(cmd.Context(), "current")
+	},
+}
+
+var cycleNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show your team's next cycle",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showCycle(cmd.Context(), "next")
+	},
+}
+
+var cycleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your team's recent and upcoming cycles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		teamID, err := listTeamID()
+		if err != nil {
+			return err
+		}
+		cycles, err := fetchCycles(ctx, client, teamID)
+		if err != nil {
+			return err
+		}
+		// Everything from the previous cycle on; older ones are history.
+		for _, c := range cycles {
+			if c.IsPast && !c.IsPrevious {
+				continue
+			}
+			marker := "  "
+			switch {
+			case c.IsActive:
+				marker = "▶ "
+			case c.IsNext:
+				marker = "→ "
+			}
+			fmt.Printf("%s%-24s %s  %3.0f%%\n", marker, cycleName(c), cycleDates(c), c.Progress*100)
+		}
+		return nil
+	},
+}
+
+var (
+	cycleCurrent bool
+	cycleNumber  string
+)
+
+var cycleAddCmd = &cobra.Command{
+	Use:   "add [issueID...] [--next|--current|--cycle N]",
+	Short: "Add issues to a cycle (the next one by default)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		which := "next"
+		switch {
+		case cycleCurrent:
+			which = "current"
+		case cycleNumber != "":
+			which = cycleNumber
+		}
+		return runBulk(cmd.Context(), args, cyclePlan(which))
+	},
+}
+
+var cycleCarryoverCmd = &cobra.Command{
+	Use:   "carryover",
+	Short: "Move unfinished issues from the current cycle into the next one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		teamID, err := listTeamID()
+		if err != nil {
+			return err
+		}
+		cycles, err := fetchCycles(ctx, client, teamID)
+		if err != nil {
+			return err
+		}
+		current, err := findCycle(cycles, "current")
+		if err != nil {
+			return err
+		}
+		// Always fresh: the cached overview may predate the last updates.
+		details, err := loadCycleDetails(ctx, client, current.Id)
+		if err != nil {
+			return err
+		}
+		var unfinished []string
+		for _, i := range details.Issues.Nodes {
+			if i.State.Type != "completed" && i.State.Type != "canceled" {
+				unfinished = append(unfinished, i.Identifier)
+			}
+		}
+		if len(unfinished) == 0 {
+			fmt.Printf("Every issue in %s is finished.\n", cycleName(current))
+			return nil
+		}
+		return runBulk(ctx, unfinished, cyclePlan("next"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cycleCmd)
+	cycleCmd.AddCommand(cycleNextCmd, cycleListCmd, cycleAddCmd, cycleCarryoverCmd)
+
+	// --next is the default; the flag only lets scripts say so explicitly.
+	cycleAddCmd.Flags().Bool("next", false, "Add to the team's next cycle (default)")
+	cycleAddCmd.Flags().BoolVar(&cycleCurrent, "current", false, "Add to the team's active cycle")
+	cycleAddCmd.Flags().StringVar(&cycleNumber, "cycle", "", "Add to the cycle with this number")
+	cycleAddCmd.MarkFlagsMutuallyExclusive("next", "current", "cycle")
+	addBulkFlags(cycleAddCmd.Flags())
+
+	cycleCarryoverCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Only preview the changes")
+	cycleCarryoverCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Apply the changes without asking for confirmation")
+}
+
+// cyclePlan moves each issue into the cycle which names (see findCycle) of
+// its own team.
+func cyclePlan(which string) func(context.Context, graphql.Client, []generated.BulkIssueFields) ([]bulkChange, error) {
+	return func(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+		changes := make([]bulkChange, len(issues))
+		for i, issue := range issues {
+			cycles, err := fetchCycles(ctx, client, issue.Team.Id)
+			if err != nil {
+				return nil, err
+			}
+			cycle, err := findCycle(cycles, which)
+			if err != nil {
+				return nil, fmt.Errorf("team %s: %w", issue.Team.Key, err)
+			}
+			from := "no cycle"
+			if issue.Cycle != nil {
+				from = fmt.Sprintf("Cycle %.0f", issue.Cycle.Number)
+			}
+			changes[i] = bulkChange{
+				issue: issue,
+				input: generated.IssueUpdateInput{CycleId: &cycle.Id},
+				from:  from,
+				to:    fmt.Sprintf("Cycle %.0f", cycle.Number),
+			}
+			if issue.Cycle != nil && issue.Cycle.Id == cycle.Id {
+				changes[i].skip = "already in " + changes[i].to
+			}
+		}
+		return changes, nil
+	}
+}
+
+// listTeamID returns the team 'list' is configured for, which cycle and
+// create commands work on.
+func listTeamID() (string, error) {
+	teamID := viper.GetString("list.team_id")
+	if teamID == "" {
+		return "", fmt.Errorf("no team configured. Please run 'quick-branch list setup' first")
+	}
+	return teamID, nil
+}
+
+// fetchCycles returns all of a team's cycles ordered by number, from the
+// cache when fresh. Linear returns them in no useful order, so every page is
+// fetched before sorting.
+func fetchCycles(ctx context.Context, client graphql.Client, teamID string) ([]generated.CycleFields, error) {
+	cycles, _, err := cached("cycles-"+teamID, func() ([]generated.CycleFields, error) {
+		cycles, err := fetchPages(func(after *string) ([]generated.CycleFields, generated.PageInfoFields, error) {
+			response, err := generated.TeamCycles(ctx, client, teamID, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, err
+			}
+			nodes := response.Team.Cycles.Nodes
+			cycles := make([]generated.CycleFields, len(nodes))
+			for i, n := range nodes {
+				cycles[i] = n.CycleFields
+			}
+			return cycles, response.Team.Cycles.PageInfo.PageInfoFields, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(cycles, func(i, j int) bool { return cycles[i].Number < cycles[j].Number })
+		return cycles, nil
+	})
+	return cycles, err
+}
+
+// findCycle picks a cycle by "current", "next", "previous" or number.
+func findCycle(cycles []generated.CycleFields, which string) (generated.CycleFields, error) {
+	var match func(generated.CycleFields) bool
+	switch strings.ToLower(which) {
+	case "current", "active":
+		match = func(c generated.CycleFields) bool { return c.IsActive }
+	case "next":
+		match = func(c generated.CycleFields) bool { return c.IsNext }
+	case "previous", "prev", "last":
+		match = func(c generated.CycleFields) bool { return c.IsPrevious }
+	default:
+		n, err := strconv.Atoi(which)
+		if err != nil {
+			return generated.CycleFields{}, fmt.Errorf("unknown cycle %q; use current, next, previous or a cycle number", which)
+		}
+		match = func(c generated.CycleFields) bool { return int(c.Number) == n }
+	}
+	for _, c := range cycles {
+		if match(c) {
+			return c, nil
+		}
+	}
+	if _, err := strconv.Atoi(which); err == nil {
+		return generated.CycleFields{}, fmt.Errorf("your team has no cycle %s", which)
+	}
+	return generated.CycleFields{}, fmt.Errorf("your team has no %s cycle", strings.ToLower(which))
+}
+
+// cycleFilter restricts an issue filter to the cycle given like findCycle
+// does, without looking the cycle up first.
+func cycleFilter(which string) (*generated.NullableCycleFilter, error) {
+	t := true
+	switch strings.ToLower(which) {
+	case "current", "active":
+		return &generated.NullableCycleFilter{IsActive: &generated.BooleanComparator{Eq: &t}}, nil
+	case "next":
+		return &generated.NullableCycleFilter{IsNext: &generated.BooleanComparator{Eq: &t}}, nil
+	case "previous", "prev", "last":
+		return &generated.NullableCycleFilter{IsPrevious: &generated.BooleanComparator{Eq: &t}}, nil
+	}
+	n, err := strconv.ParseFloat(which, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unknown cycle %q; use current, next, previous or a cycle number", which)
+	}
+	return &generated.NullableCycleFilter{Number: &generated.NumberComparator{Eq: &n}}, nil
+}
+
+func fetchCycleDetails(ctx context.Context, client graphql.Client, id string) (generated.CycleDetailsCycle, error) {
+	cycle, _, err := cached("cycle-"+id, func() (generated.CycleDetailsCycle, error) {
+		return loadCycleDetails(ctx, client, id)
+	})
+	return cycle, err
+}
+
+// loadCycleDetails fetches a cycle with every page of its issues, so totals
+// and carryover cover the whole cycle.
+func loadCycleDetails(ctx context.Context, client graphql.Client, id string) (generated.CycleDetailsCycle, error) {
+	var cycle generated.CycleDetailsCycle
+	issues, err := fetchPages(func(after *string) ([]generated.CycleDetailsCycleIssuesIssueConnectionNodesIssue, generated.PageInfoFields, error) {
+		response, err := generated.CycleDetails(ctx, client, id, after)
+		if err != nil {
+			return nil, generated.PageInfoFields{}, err
+		}
+		cycle = response.Cycle
+		return cycle.Issues.Nodes, cycle.Issues.PageInfo.PageInfoFields, nil
+	})
+	if err != nil {
+		return generated.CycleDetailsCycle{}, err
+	}
+	cycle.Issues.Nodes = issues
+	return cycle, nil
+}
+
+func showCycle(ctx context.Context, which string) error {
+	client, err := newGraphQLClient()
+	if err != nil {
+		return err
+	}
+	teamID, err := listTeamID()
+	if err != nil {
+		return err
+	}
+	cycles, err := fetchCycles(ctx, client, teamID)
+	if err != nil {
+		return err
+	}
+	c, err := findCycle(cycles, which)
+	if err != nil {
+		return err
+	}
+	details, err := fetchCycleDetails(ctx, client, c.Id)
+	if err != nil {
+		return err
+	}
+	printCycle(details)
+	return nil
+}
+
+func cycleName(c generated.CycleFields) string {
+	name := fmt.Sprintf("Cycle %.0f", c.Number)
+	if c.Name != nil && *c.Name != "" {
+		name += " · " + *c.Name
+	}
+	return name
+}
+
+func cycleDates(c generated.CycleFields) string {
+	return c.StartsAt.Local().Format("Jan 2") + " – " + c.EndsAt.Local().Format("Jan 2")
+}
+
+// cycleDays is the length of a cycle in whole days.
+func cycleDays(c generated.CycleFields) int {
+	return max(int(math.Round(c.EndsAt.Sub(c.StartsAt).Hours()/24)), 1)
+}
+
+// stateOrder sorts workflow state types the way a cycle is worked through.
+var stateOrder = map[string]int{"started": 0, "unstarted": 1, "backlog": 2, "triage": 3, "completed": 4, "canceled": 5}
+
+func printCycle(c generated.CycleDetailsCycle) {
+	fmt.Println(headingStyle.Render(cycleName(c.CycleFields)))
+
+	when := cycleDates(c.CycleFields)
+	days := cycleDays(c.CycleFields)
+	switch {
+	case c.IsActive:
+		day := min(int(time.Since(c.StartsAt).Hours()/24)+1, days)
+		left := int(math.Ceil(time.Until(c.EndsAt).Hours() / 24))
+		when += fmt.Sprintf(" · day %d of %d · %s left", day, days, plural(max(left, 0), "day"))
+	case c.IsFuture:
+		when += fmt.Sprintf(" · starts in %s", plural(int(math.Ceil(time.Until(c.StartsAt).Hours()/24)), "day"))
+	case c.CompletedAt != nil:
+		when += " · completed"
+	}
+	fmt.Println(mutedStyle.Render(when))
+	fmt.Println()
+
+	fmt.Printf("Progress   %s\n", progressBar(c.Progress, 24))
+
+	var issues, done int
+	var points, donePoints float64
+	for _, i := range c.Issues.Nodes {
+		if i.State.Type == "canceled" {
+			continue
+		}
+		issues++
+		estimate := 0.0
+		if i.Estimate != nil {
+			estimate = *i.Estimate
+		}
+		points += estimate
+		if i.State.Type == "completed" {
+			done++
+			donePoints += estimate
+		}
+	}
+	amount := func(n int, p float64) string {
+		s := plural(n, "issue")
+		if points > 0 {
+			s += ", " + plural(int(p), "point")
+		}
+		return s
+	}
+	fmt.Printf("Scope      %s\n", amount(issues, points))
+	fmt.Printf("Completed  %s\n", amount(done, donePoints))
+	fmt.Printf("Remaining  %s\n", amount(issues-done, points-donePoints))
+
+	printCycleIssues(c.Issues.Nodes)
+
+	if c.IsFuture {
+		return
+	}
+	if burndown := renderBurndown(c); burndown != "" {
+		fmt.Println()
+		fmt.Println(burndown)
+	}
+}
+
+func printCycleIssues(issues []generated.CycleDetailsCycleIssuesIssueConnectionNodesIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i].State, issues[j].State
+		if stateOrder[a.Type] != stateOrder[b.Type] {
+			return stateOrder[a.Type] < stateOrder[b.Type]
+		}
+		return a.Position < b.Position
+	})
+
+	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || termWidth == 0 {
+		termWidth = 100
+	}
+	state := ""
+	for idx, i := range issues {
+		if i.State.Name != state {
+			state = i.State.Name
+			count := 0
+			for _, j := range issues[idx:] {
+				if j.State.Name == state {
+					count++
+				}
+			}
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(i.State.Color)).Bold(true)
+			fmt.Printf("\n%s %s\n", style.Render(state), mutedStyle.Render(strconv.Itoa(count)))
+		}
+		var extra []string
+		if i.Assignee != nil {
+			extra = append(extra, i.Assignee.Name)
+		}
+		if i.Estimate != nil {
+			extra = append(extra, strconv.FormatFloat(*i.Estimate, 'f', -1, 64)+"pt")
+		}
+		suffix := ""
+		if len(extra) > 0 {
+			suffix = " · " + strings.Join(extra, " · ")
+		}
+		title := truncate(i.Title, max(termWidth-14-lipgloss.Width(suffix), 10))
+		fmt.Printf("  %-10s %s%s\n", i.Identifier, title, mutedStyle.Render(suffix))
+	}
+}
+
+// progressBar draws a fraction between 0 and 1 as a bar width cells wide,
+// followed by the percentage.
+func progressBar(progress float64, width int) string {
+	filled := min(max(int(math.Round(progress*float64(width))), 0), width)
+	return barStyle.Render(strings.Repeat("█", filled)) +
+		mutedStyle.Render(strings.Repeat("░", width-filled)) +
+		fmt.Sprintf(" %.0f%%", progress*100)
+}
+
+// renderBurndown charts the work remaining after each day of the cycle
+// against an ideal straight line to zero. Estimates are used when the cycle
+// has any, issue counts otherwise. It returns "" when there is no history.
+func renderBurndown(c generated.CycleDetailsCycle) string {
+	scope, completed, unit := c.ScopeHistory, c.CompletedScopeHistory, "points"
+	if maxValue(scope) == 0 {
+		scope, completed, unit = c.IssueCountHistory, c.CompletedIssueCountHistory, "issues"
+	}
+	remaining := make([]float64, len(scope))
+	for i := range scope {
+		remaining[i] = scope[i]
+		if i < len(completed) {
+			remaining[i] -= completed[i]
+		}
+	}
+	days := cycleDays(c.CycleFields)
+	// Nothing to chart yet, or no days to spread the ideal line over.
+	if len(remaining) == 0 || days <= 0 {
+		return ""
+	}
+
+	top := max(maxValue(remaining), 1)
+	height := func(v float64) int { return int(math.Round(v / top * burndownHeight)) }
+	ideal := func(d int) float64 { return remaining[0] * (1 - float64(d)/float64(days)) }
+
+	colW := 3
+	if (days+1)*colW > 72 {
+		colW = 1
+	}
+	bar := strings.Repeat("█", max(colW-1, 1))
+	dot := strings.Repeat(" ", max(colW-2, 0)) + "·"
+	blank := strings.Repeat(" ", len([]rune(bar)))
+	gap := strings.Repeat(" ", colW-len([]rune(bar)))
+
+	var b strings.Builder
+	b.WriteString(headingStyle.Render("Burndown") + mutedStyle.Render(" ("+unit+" remaining)") + "\n")
+	for row := burndownHeight; row >= 1; row-- {
+		label := ""
+		if row == burndownHeight {
+			label = strconv.FormatFloat(top, 'f', 0, 64)
+		}
+		fmt.Fprintf(&b, "%5s │", label)
+		for d := 0; d <= days; d++ {
+			switch {
+			case d < len(remaining) && height(remaining[d]) >= row:
+				b.WriteString(barStyle.Render(bar))
+			case height(ideal(d)) == row:
+				b.WriteString(mutedStyle.Render(dot))
+			default:
+				b.WriteString(blank)
+			}
+			b.WriteString(gap)
+		}
+		b.WriteString("\n")
+	}
+	axis := (days + 1) * colW
+	fmt.Fprintf(&b, "%5s └%s\n", "0", strings.Repeat("─", axis))
+	start, end := c.StartsAt.Local().Format("Jan 2"), c.EndsAt.Local().Format("Jan 2")
+	fmt.Fprintf(&b, "%5s  %s%*s\n", "", start, max(axis-len(start), len(end)), end)
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("%5s  %s remaining  · ideal", "", bar)))
+	return b.String()
+}
+
+func maxValue(values []float64) float64 {
+	m := 0.0
+	for _, v := range values {
+		m = max(m, v)
+	}
+	return m
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	debug     bool
+	traceFile string
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveName matches header and variable names whose values are never
+// logged.
+var sensitiveName = regexp.MustCompile(`(?i)authorization|api[_-]?key|token|secret|password`)
+
+// debugTransport logs every request Linear sees with --debug and appends
+// full request/response pairs to --trace-file. It sits below
+// authorizedTransport, so it records each retry and the headers actually
+// sent, with the credential redacted.
+type debugTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+	path   string
+	mu     sync.Mutex
+}
+
+// withDebug wraps base in a debugTransport when --debug or --trace-file is
+// set, and returns it unchanged otherwise.
+func withDebug(base http.RoundTripper) http.RoundTripper {
+	if !debug && traceFile == "" {
+		return base
+	}
+	t := &debugTransport{base: base, path: traceFile}
+	if debug {
+		t.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return t
+}
+
+// traceRecord is one line of the --trace-file JSONL output.
+type traceRecord struct {
+	Time       time.Time     `json:"time"`
+	Operation  string        `json:"operation"`
+	DurationMS int64         `json:"duration_ms"`
+	Request    traceMessage  `json:"request"`
+	Response   *traceMessage `json:"response,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type traceMessage struct {
+	Method  string              `json:"method,omitempty"`
+	URL     string              `json:"url,omitempty"`
+	Status  int                 `json:"status,omitempty"`
+	Headers map[string][]string `json:"headers"`
+	Body    json.RawMessage     `json:"body,omitempty"`
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	secret := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	var payload struct {
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	json.Unmarshal(reqBody, &payload)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	var respBody []byte
+	if resp != nil {
+		var readErr error
+		respBody, readErr = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		if readErr != nil && err == nil {
+			err = readErr
+		}
+	}
+
+	if t.logger != nil {
+		attrs := []any{
+			"operation", payload.OperationName,
+			"variables", string(redactJSON(payload.Variables, secret)),
+			"duration", elapsed.Round(time.Millisecond),
+		}
+		if resp != nil {
+			attrs = append(attrs, "status", resp.StatusCode, "bytes", len(respBody))
+			for _, kind := range []string{"Requests", "Complexity"} {
+				if limit := resp.Header.Get("X-RateLimit-" + kind + "-Limit"); limit != "" {
+					attrs = append(attrs, "ratelimit_"+strings.ToLower(kind),
+						resp.Header.Get("X-RateLimit-"+kind+"-Remaining")+"/"+limit)
+				}
+			}
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+			t.logger.Debug("graphql request failed", attrs...)
+		} else {
+			t.logger.Debug("graphql request", attrs...)
+		}
+	}
+
+	if t.path != "" {
+		rec := traceRecord{
+			Time:       start,
+			Operation:  payload.OperationName,
+			DurationMS: elapsed.Milliseconds(),
+			Request: traceMessage{
+				Method:  req.Method,
+				URL:     req.URL.String(),
+				Headers: redactHeaders(req.Header),
+				Body:    redactJSON(reqBody, secret),
+			},
+		}
+		if resp != nil {
+			rec.Response = &traceMessage{
+				Status:  resp.StatusCode,
+				Headers: redactHeaders(resp.Header),
+				Body:    redactJSON(respBody, secret),
+			}
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if werr := t.writeTrace(rec); werr != nil && t.logger != nil {
+			t.logger.Warn("failed to write trace file", "path", t.path, "error", werr)
+		}
+	}
+	return resp, err
+}
+
+func (t *debugTransport) writeTrace(rec traceRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.OpenFile(t.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func redactHeaders(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for name, values := range h {
+		if sensitiveName.MatchString(name) {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = values
+	}
+	return out
+}
+
+// redactJSON blanks out fields with sensitive names and any occurrence of
+// secret in a JSON document. Invalid JSON is stored as a JSON string.
+func redactJSON(data []byte, secret string) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		s, _ := json.Marshal(redactString(string(data), secret))
+		return s
+	}
+	out, err := json.Marshal(redactValue(v, secret))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func redactValue(v any, secret string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if sensitiveName.MatchString(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(child, secret)
+			}
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, secret)
+		}
+		return v
+	case string:
+		return redactString(v, secret)
+	default:
+		return v
+	}
+}
+
+func redactString(s, secret string) string {
+	if secret == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, secret, redacted)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import "testing"
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		secret string
+		want   string
+	}{
+		{name: "empty", data: "", want: ""},
+		{
+			name: "sensitive names",
+			data: `{"apiKey":"k","api_key":"k","refresh_token":"t","clientSecret":"s","name":"Jane"}`,
+			want: `{"apiKey":"[REDACTED]","api_key":"[REDACTED]","clientSecret":"[REDACTED]","name":"Jane","refresh_token":"[REDACTED]"}`,
+		},
+		{
+			name:   "secret inside values",
+			data:   `{"query":"query Me","variables":{"note":"key lin_api_123 leaked"},"list":["lin_api_123"]}`,
+			secret: "lin_api_123",
+			want:   `{"list":["[REDACTED]"],"query":"query Me","variables":{"note":"key [REDACTED] leaked"}}`,
+		},
+		{
+			name: "nested objects",
+			data: `{"data":{"viewer":{"id":"u1","authorization":{"token":"x"}}}}`,
+			want: `{"data":{"viewer":{"authorization":"[REDACTED]","id":"u1"}}}`,
+		},
+		{
+			name:   "invalid JSON becomes a string",
+			data:   `oops lin_api_123`,
+			secret: "lin_api_123",
+			want:   `"oops [REDACTED]"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactJSON([]byte(tt.data), tt.secret)); got != tt.want {
+				t.Errorf("redactJSON(%s) = %s, want %s", tt.data, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// linearError is an error response from Linear rewritten into something the
+// user can act on. The original error stays reachable through Unwrap.
+type linearError struct {
+	message  string
+	notFound bool
+	err      error
+}
+
+func (e *linearError) Error() string { return e.message }
+func (e *linearError) Unwrap() error { return e.err }
+
+// translateError maps the GraphQL errors in a genqlient error to a
+// linearError, using the code, type and userPresentableMessage extensions
+// Linear attaches. Errors without GraphQL details are returned unchanged.
+func translateError(err error) error {
+	var httpErr *graphql.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+		return &linearError{message: authFailedMessage, err: err}
+	}
+
+	errs := graphQLErrors(err)
+	if len(errs) == 0 {
+		return err
+	}
+	first := errs[0]
+	code, _ := first.Extensions["code"].(string)
+	kind, _ := first.Extensions["type"].(string)
+	userMessage, _ := first.Extensions["userPresentableMessage"].(string)
+	lower := strings.ToLower(first.Message + " " + userMessage)
+
+	e := &linearError{err: err}
+	switch {
+	case code == "AUTHENTICATION_ERROR" || kind == "authentication error":
+		e.message = authFailedMessage
+	case code == "FORBIDDEN" || kind == "forbidden":
+		e.message = "your Linear account or API key doesn't have permission to do that"
+		if userMessage != "" {
+			e.message += ": " + userMessage
+		}
+	case strings.Contains(lower, "not found") || strings.Contains(lower, "could not find"):
+		e.notFound = true
+		e.message = first.Message
+		if userMessage != "" {
+			e.message = userMessage
+		}
+	case userMessage != "":
+		e.message = userMessage
+	default:
+		e.message = first.Message
+	}
+	return e
+}
+
+// graphQLErrors returns the GraphQL errors carried by a genqlient error.
+func graphQLErrors(err error) gqlerror.List {
+	var list gqlerror.List
+	if errors.As(err, &list) {
+		return list
+	}
+	var httpErr *graphql.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Response.Errors
+	}
+	return nil
+}
+
+const authFailedMessage = "Linear rejected your credentials; the API key may have been revoked or expired. Run 'quick-branch auth' to log in again"
+
+func isNotFound(err error) bool {
+	var e *linearError
+	return errors.As(err, &e) && e.notFound
+}
+
+// issueError names the issue in not-found errors, which Linear reports
+// without saying which entity was missing.
+func issueError(err error, issueID string) error {
+	if isNotFound(err) {
+		return &linearError{
+			message:  fmt.Sprintf("issue %s not found in your workspace", strings.ToUpper(issueID)),
+			notFound: true,
+			err:      err,
+		}
+	}
+	return err
+}
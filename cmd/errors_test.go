@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func linearGraphQLError(message string, extensions map[string]any) error {
+	return gqlerror.List{{Message: message, Path: ast.Path{ast.PathName("issue")}, Extensions: extensions}}
+}
+
+func TestTranslateError(t *testing.T) {
+	plain := errors.New("dial tcp: connection refused")
+
+	tests := []struct {
+		name         string
+		err          error
+		want         string
+		wantNotFound bool
+	}{
+		{name: "not a GraphQL error", err: plain, want: plain.Error()},
+		{
+			name: "HTTP 401",
+			err:  &graphql.HTTPError{StatusCode: http.StatusUnauthorized},
+			want: authFailedMessage,
+		},
+		{
+			name: "authentication code",
+			err:  linearGraphQLError("Authentication required", map[string]any{"code": "AUTHENTICATION_ERROR"}),
+			want: authFailedMessage,
+		},
+		{
+			name: "forbidden with explanation",
+			err: linearGraphQLError("Forbidden", map[string]any{
+				"type":                   "forbidden",
+				"userPresentableMessage": "You need to be a team member.",
+			}),
+			want: "your Linear account or API key doesn't have permission to do that: You need to be a team member.",
+		},
+		{
+			name:         "entity not found",
+			err:          linearGraphQLError("Entity not found: Issue", map[string]any{"code": "INPUT_ERROR"}),
+			want:         "Entity not found: Issue",
+			wantNotFound: true,
+		},
+		{
+			name:         "not found with explanation",
+			err:          linearGraphQLError("Could not find referenced Issue.", map[string]any{"userPresentableMessage": "Issue does not exist."}),
+			want:         "Issue does not exist.",
+			wantNotFound: true,
+		},
+		{
+			name: "user presentable message",
+			err:  linearGraphQLError("Argument Validation Error", map[string]any{"userPresentableMessage": "Title is too long."}),
+			want: "Title is too long.",
+		},
+		{
+			name: "bare message",
+			err:  linearGraphQLError("Something broke", nil),
+			want: "Something broke",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if got.Error() != tt.want {
+				t.Errorf("translateError() = %q, want %q", got, tt.want)
+			}
+			if isNotFound(got) != tt.wantNotFound {
+				t.Errorf("isNotFound() = %v, want %v", isNotFound(got), tt.wantNotFound)
+			}
+			if len(graphQLErrors(got)) != len(graphQLErrors(tt.err)) {
+				t.Errorf("translated error no longer wraps the original GraphQL errors")
+			}
+		})
+	}
+}
+
+func TestIssueError(t *testing.T) {
+	notFound := translateError(linearGraphQLError("Entity not found: Issue", nil))
+	if got := issueError(notFound, "eng-42").Error(); got != "issue ENG-42 not found in your workspace" {
+		t.Errorf("issueError() = %q", got)
+	}
+	forbidden := translateError(linearGraphQLError("Forbidden", map[string]any{"code": "FORBIDDEN"}))
+	if got := issueError(forbidden, "ENG-42"); got != forbidden {
+		t.Errorf("issueError() rewrote an error that isn't a missing issue: %v", got)
+	}
+}
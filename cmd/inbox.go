@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inboxCount bool
+	snoozeFor  string
+)
+
+var unreadStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#5E6AD2"))
+
+// inboxItem is a notification as the inbox shows it. genqlient returns
+// notifications as interfaces, which can't be cached, so they are copied
+// into this struct.
+type inboxItem struct {
+	ID           string     `json:"id"`
+	Category     string     `json:"category"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	Read         bool       `json:"read"`
+	SnoozedUntil *time.Time `json:"snoozedUntil,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	Actor        string     `json:"actor"`
+	Issue        string     `json:"issue"`
+	IssueURL     string     `json:"issueUrl"`
+}
+
+var inboxCmd = &cobra.Command{
+	Use:   "inbox",
+	Short: "Show your Linear notifications",
+	Long: `inbox lists your notifications, newest first, with unread ones marked ●.
+Snoozed notifications stay hidden until they wake up.
+
+The read, archive, snooze and open subcommands take notifications by their
+number in the list last printed or by issue identifier, which picks every
+notification about that issue.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		if inboxCount {
+			count, _, err := cached("inbox-count", func() (int, error) {
+				response, err := generated.NotificationsUnreadCount(ctx, client)
+				if err != nil {
+					return 0, err
+				}
+				return response.NotificationsUnreadCount, nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(count)
+			return nil
+		}
+
+		items, err := fetchInbox(ctx, client)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println("Inbox zero!")
+			return nil
+		}
+		t := compactTable("#", "", "TYPE", "ISSUE", "TITLE", "FROM", "AGE")
+		for i, item := range items {
+			marker := ""
+			if !item.Read {
+				marker = unreadStyle.Render("●")
+			}
+			t.Row(
+				strconv.Itoa(i+1),
+				marker,
+				categoryLabel(item.Category),
+				item.Issue,
+				truncate(item.Title, 50),
+				item.Actor,
+				shortAge(item.CreatedAt),
+			)
+		}
+		fmt.Println(t)
+		fmt.Println(mutedStyle.Render("quick-branch inbox read|archive|snooze|open <# or issue>"))
+		savePrintedInbox(items)
+		return nil
+	},
+}
+
+var inboxReadCmd = &cobra.Command{
+	Use:   "read <#|issueID|all>...",
+	Short: "Mark notifications as read",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		return updateInbox(cmd.Context(), args, true, "Marked %s as read", func(ctx context.Context, client graphql.Client, item inboxItem) error {
+			_, err := generated.NotificationUpdate(ctx, client, item.ID, generated.NotificationUpdateInput{ReadAt: &now})
+			return err
+		})
+	},
+}
+
+var inboxArchiveCmd = &cobra.Command{
+	Use:   "archive <#|issueID>...",
+	Short: "Archive notifications",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateInbox(cmd.Context(), args, false, "Archived %s", func(ctx context.Context, client graphql.Client, item inboxItem) error {
+			_, err := generated.NotificationArchive(ctx, client, item.ID)
+			return err
+		})
+	},
+}
+
+var inboxSnoozeCmd = &cobra.Command{
+	Use:   "snooze <#|issueID>...",
+	Short: "Hide notifications for a while",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := parseSnooze(snoozeFor)
+		if err != nil {
+			return err
+		}
+		until := time.Now().Add(d)
+		message := "Snoozed %s until " + until.Format("Mon Jan 2 15:04")
+		return updateInbox(cmd.Context(), args, false, message, func(ctx context.Context, client graphql.Client, item inboxItem) error {
+			_, err := generated.NotificationUpdate(ctx, client, item.ID, generated.NotificationUpdateInput{SnoozedUntilAt: &until})
+			return err
+		})
+	},
+}
+
+var inboxOpenCmd = &cobra.Command{
+	Use:   "open <#|issueID>",
+	Short: "Open a notification's issue in the browser and mark it read",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		items, err := inboxTargets(ctx, client, args, false)
+		if err != nil {
+			return err
+		}
+		target := items[0].IssueURL
+		if target == "" {
+			target = items[0].URL
+		}
+		openURL(target)
+
+		now := time.Now()
+		defer invalidateCache("inbox")
+		for _, item := range items {
+			if item.Read {
+				continue
+			}
+			if _, err := generated.NotificationUpdate(ctx, client, item.ID, generated.NotificationUpdateInput{ReadAt: &now}); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inboxCmd)
+	inboxCmd.AddCommand(inboxReadCmd, inboxArchiveCmd, inboxSnoozeCmd, inboxOpenCmd)
+
+	inboxCmd.Flags().BoolVar(&inboxCount, "count", false, "Only print the number of unread notifications, e.g. for a shell prompt")
+	inboxSnoozeCmd.Flags().StringVar(&snoozeFor, "for", "1d", "How long to snooze: a duration like 3h, 2d or 1w")
+}
+
+// fetchInbox returns the notifications that aren't snoozed, newest first,
+// from the cache when fresh.
+func fetchInbox(ctx context.Context, client graphql.Client) ([]inboxItem, error) {
+	items, _, err := cached("inbox", func() ([]inboxItem, error) {
+		return fetchPages(func(after *string) ([]inboxItem, generated.PageInfoFields, error) {
+			response, err := generated.Notifications(ctx, client, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, err
+			}
+			var items []inboxItem
+			for _, node := range response.Notifications.Nodes {
+				if item, ok := newInboxItem(node); ok {
+					items = append(items, item)
+				}
+			}
+			return items, response.Notifications.PageInfo.PageInfoFields, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter after caching so snoozed notifications reappear on time.
+	var awake []inboxItem
+	for _, item := range items {
+		if item.SnoozedUntil == nil || item.SnoozedUntil.Before(time.Now()) {
+			awake = append(awake, item)
+		}
+	}
+	return awake, nil
+}
+
+// newInboxItem copies a notification into an inboxItem, reporting false for
+// a node that isn't a notification at all.
+func newInboxItem(node generated.NotificationsNotificationsNotificationConnectionNodesNotification) (inboxItem, bool) {
+	n, ok := node.(generated.NotificationFields)
+	if !ok {
+		return inboxItem{}, false
+	}
+	item := inboxItem{
+		ID:           n.GetId(),
+		Category:     string(n.GetCategory()),
+		Title:        n.GetTitle(),
+		URL:          n.GetUrl(),
+		Read:         n.GetReadAt() != nil,
+		SnoozedUntil: n.GetSnoozedUntilAt(),
+		CreatedAt:    n.GetCreatedAt(),
+	}
+	if actor := n.GetActor(); actor != nil {
+		item.Actor = actor.Name
+	}
+	if in, ok := node.(interface {
+		GetIssue() generated.NotificationFieldsIssue
+	}); ok {
+		item.Issue = in.GetIssue().Identifier
+		item.IssueURL = in.GetIssue().Url
+	}
+	return item, true
+}
+
+// inboxTargets resolves list numbers and issue identifiers (and "all" when
+// allowAll) to notifications in the current inbox.
+func inboxTargets(ctx context.Context, client graphql.Client, refs []string, allowAll bool) ([]inboxItem, error) {
+	items, err := fetchInbox(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return matchInboxRefs(items, refs, allowAll)
+}
+
+// matchInboxRefs picks the items refs name, each at most once, in the order
+// they are first named.
+func matchInboxRefs(items []inboxItem, refs []string, allowAll bool) ([]inboxItem, error) {
+	var targets []inboxItem
+	seen := make(map[string]bool)
+	add := func(item inboxItem) {
+		if !seen[item.ID] {
+			seen[item.ID] = true
+			targets = append(targets, item)
+		}
+	}
+	for _, ref := range refs {
+		if allowAll && strings.EqualFold(ref, "all") {
+			for _, item := range items {
+				add(item)
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(ref); err == nil {
+			item, err := printedInboxItem(items, n)
+			if err != nil {
+				return nil, err
+			}
+			add(item)
+			continue
+		}
+		found := false
+		for _, item := range items {
+			if strings.EqualFold(item.Issue, ref) {
+				add(item)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no notifications about %s", strings.ToUpper(ref))
+		}
+	}
+	return targets, nil
+}
+
+// printedInboxKey is where the inbox remembers the notifications it last
+// printed. The key doesn't start with "inbox", so updates don't clear it.
+const printedInboxKey = "printed-inbox"
+
+// savePrintedInbox remembers the order items were printed in, so their
+// numbers keep meaning the same notifications however the inbox changes.
+func savePrintedInbox(items []inboxItem) {
+	if noCache {
+		return
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	if err := writeCache(printedInboxKey, ids); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save the inbox numbers: %v\n", err)
+	}
+}
+
+// printedInboxItem returns notification n of the inbox as last printed,
+// looked up among the current items.
+func printedInboxItem(items []inboxItem, n int) (inboxItem, error) {
+	var ids []string
+	if noCache {
+		// Fixtures can't remember a listing, so number the current inbox.
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+	} else {
+		printed, err := readCache[[]string](printedInboxKey)
+		if err != nil {
+			return inboxItem{}, fmt.Errorf("no numbered inbox to refer to; run 'quick-branch inbox' first")
+		}
+		ids = printed.Data
+	}
+	if n < 1 || n > len(ids) {
+		return inboxItem{}, fmt.Errorf("no notification #%d; the inbox last printed had %s", n, plural(len(ids), "notification"))
+	}
+	for _, item := range items {
+		if item.ID == ids[n-1] {
+			return item, nil
+		}
+	}
+	return inboxItem{}, fmt.Errorf("notification #%d is no longer in your inbox; run 'quick-branch inbox' to see it now", n)
+}
+
+// updateInbox applies update to every notification refs name and reports
+// how many changed, using message with a "%s" for the count.
+func updateInbox(ctx context.Context, refs []string, allowAll bool, message string, update func(context.Context, graphql.Client, inboxItem) error) error {
+	client, err := newGraphQLClient()
+	if err != nil {
+		return err
+	}
+	items, err := inboxTargets(ctx, client, refs, allowAll)
+	if err != nil {
+		return err
+	}
+	// Notifications updated before a failure are still changed.
+	defer invalidateCache("inbox")
+	for _, item := range items {
+		if err := update(ctx, client, item); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Success! "+message+"\n", plural(len(items), "notification"))
+	return nil
+}
+
+// categoryLabel names a notification category in a word or two.
+func categoryLabel(category string) string {
+	switch generated.NotificationCategory(category) {
+	case generated.NotificationCategoryAssignments:
+		return "Assigned"
+	case generated.NotificationCategoryMentions:
+		return "Mention"
+	case generated.NotificationCategoryCommentsandreplies:
+		return "Comment"
+	case generated.NotificationCategoryStatuschanges:
+		return "Status"
+	case generated.NotificationCategoryReactions:
+		return "Reaction"
+	case generated.NotificationCategoryReviews:
+		return "Review"
+	case generated.NotificationCategoryReminders:
+		return "Reminder"
+	case generated.NotificationCategoryTriage:
+		return "Triage"
+	case generated.NotificationCategorySubscriptions, generated.NotificationCategoryPostsandupdates:
+		return "Update"
+	}
+	if category == "" {
+		return "—"
+	}
+	return strings.ToUpper(category[:1]) + category[1:]
+}
+
+// shortAge is how long ago t was, e.g. "5m", "3h" or "2d".
+func shortAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	return t.Format("Jan 2")
+}
+
+// parseSnooze parses a Go duration, plus days ("2d") and weeks ("1w").
+func parseSnooze(s string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(s[:len(s)-1])); err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid snooze duration %q; use something like 3h, 2d or 1w", s)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSnooze(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "3h", want: 3 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1w", want: 7 * 24 * time.Hour},
+		{in: "0d", wantErr: true},
+		{in: "-2h", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "tomorrow", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSnooze(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSnooze(%q) = %s, %v; want %s (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrintedInboxItem(t *testing.T) {
+	items := []inboxItem{{ID: "n1"}, {ID: "n2"}, {ID: "n4"}}
+
+	isolateCache(t)
+	if _, err := printedInboxItem(items, 1); err == nil || !strings.Contains(err.Error(), "run 'quick-branch inbox' first") {
+		t.Errorf("with nothing printed yet, got error %v", err)
+	}
+
+	// n3 was printed as #3 but has since been archived.
+	if err := writeCache(printedInboxKey, []string{"n1", "n2", "n3", "n4"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		n       int
+		want    string
+		wantErr string
+	}{
+		{n: 1, want: "n1"},
+		{n: 4, want: "n4"},
+		{n: 0, wantErr: "no notification #0; the inbox last printed had 4 notifications"},
+		{n: 5, wantErr: "no notification #5"},
+		{n: 3, wantErr: "notification #3 is no longer in your inbox"},
+	}
+	for _, tt := range tests {
+		got, err := printedInboxItem(items, tt.n)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("printedInboxItem(%d) error = %v, want %q", tt.n, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.ID != tt.want {
+			t.Errorf("printedInboxItem(%d) = %s, %v; want %s", tt.n, got.ID, err, tt.want)
+		}
+	}
+
+	// Without a cache the numbers follow the current inbox.
+	noCache = true
+	t.Cleanup(func() { noCache = false })
+	if got, err := printedInboxItem(items, 3); err != nil || got.ID != "n4" {
+		t.Errorf("printedInboxItem(3) without a cache = %s, %v; want n4", got.ID, err)
+	}
+}
+
+func TestMatchInboxRefs(t *testing.T) {
+	items := []inboxItem{
+		{ID: "n1", Issue: "ENG-42"},
+		{ID: "n2", Issue: "ENG-7"},
+		{ID: "n3", Issue: "ENG-42"},
+		{ID: "n4"},
+	}
+	isolateCache(t)
+	savePrintedInbox(items)
+
+	tests := []struct {
+		name     string
+		refs     []string
+		allowAll bool
+		want     []string
+		wantErr  string
+	}{
+		{name: "numbers", refs: []string{"2", "1"}, want: []string{"n2", "n1"}},
+		{name: "every notification about an issue", refs: []string{"eng-42"}, want: []string{"n1", "n3"}},
+		{name: "duplicates dropped", refs: []string{"1", "ENG-42", "3"}, want: []string{"n1", "n3"}},
+		{name: "all", refs: []string{"2", "all"}, allowAll: true, want: []string{"n2", "n1", "n3", "n4"}},
+		{name: "all not allowed", refs: []string{"all"}, wantErr: "no notifications about ALL"},
+		{name: "unknown issue", refs: []string{"ENG-1"}, wantErr: "no notifications about ENG-1"},
+		{name: "bad number", refs: []string{"1", "9"}, wantErr: "no notification #9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchInboxRefs(items, tt.refs, tt.allowAll)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, item := range got {
+				ids = append(ids, item.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("matched %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
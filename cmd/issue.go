@@ -3,11 +3,10 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os/exec"
+	"strings"
+	"text/template"
 
-	"github.com/Khan/genqlient/graphql"
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/rangoons/quick-branch/internal/generated"
@@ -16,10 +15,14 @@ import (
 )
 
 var (
-	url         bool
-	branch      bool
-	checkout    bool
-	description bool
+	url          bool
+	branch       bool
+	checkout     bool
+	description  bool
+	showTree     bool
+	children     bool
+	openIssue    bool
+	copyTemplate string
 )
 
 // issueCmd represents the issue command
@@ -33,7 +36,7 @@ var issueCmd = &cobra.Command{
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		issueID := args[0]
-		issue, err := fetchIssue(issueID)
+		issue, err := fetchIssue(cmd.Context(), issueID)
 		if err != nil {
 			fmt.Printf("Error: %v", err)
 			return
@@ -45,37 +48,70 @@ var issueCmd = &cobra.Command{
 			// Style the state with its color from Linear
 			stateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(issue.State.Color))
 
-			fmt.Printf("%s: %s\n\n",
+			fmt.Printf("%s: %s\n",
 				titleStyle.Render(issue.Title),
 				stateStyle.Render(issue.State.Name))
+			if labels := issueLabels(issue.Labels.Nodes); len(labels) > 0 {
+				fmt.Println("Labels:", renderLabels(labels))
+			}
+			printRelations(issueRelations(issue.Relations.Nodes, issue.InverseRelations.Nodes))
+			if attachments := issue.Attachments.Nodes; len(attachments) > 0 {
+				fmt.Println("Attachments:")
+				for _, a := range attachments {
+					title := a.Title
+					if a.Subtitle != nil && *a.Subtitle != "" {
+						title += " · " + *a.Subtitle
+					}
+					fmt.Printf("  %s %s\n", title, mutedStyle.Render(a.Url))
+				}
+			}
+			fmt.Println()
 
-			// Render the markdown description prettily
-			renderer, err := glamour.NewTermRenderer(
-				glamour.WithAutoStyle(),
-			)
-			if err == nil {
-				out, err := renderer.Render(*issue.Description)
-				if err == nil {
-					fmt.Print(out)
+			if issue.Description != nil {
+				fmt.Print(renderMarkdown(*issue.Description))
+			}
+		}
+		if showTree || children {
+			client, err := newGraphQLClient()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if showTree {
+				t, err := fetchIssueTree(cmd.Context(), client, issueID)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				printIssueTree(t)
+			}
+			if children {
+				issues, err := fetchChildren(cmd.Context(), client, issue)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				if len(issues) == 0 {
+					fmt.Printf("%s has no sub-issues.\n", issue.Identifier)
 				} else {
-					// Fallback to plain text if rendering fails
-					fmt.Println(issue.Description)
+					printIssueTable(issues)
 				}
-			} else {
-				// Fallback to plain text if renderer creation fails
-				fmt.Println(issue.Description)
 			}
 		}
+		if openIssue {
+			openURL(issue.Url)
+		}
 		if url {
-			err := clipboard.WriteAll(issue.Url)
-			if err == nil {
-				fmt.Println("Copied issue url to clipboard")
-			}
+			copyAndReport("issue url", issue.Url)
 		} else if branch {
-			err := clipboard.WriteAll(issue.BranchName)
-			if err == nil {
-				fmt.Println("Copied branch name to clipboard")
+			copyAndReport("branch name", issue.BranchName)
+		} else if copyTemplate != "" {
+			text, err := renderCopyTemplate(copyTemplate, issue)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
 			}
+			copyAndReport(fmt.Sprintf("%q", text), text)
 		}
 		if checkout {
 			if err := checkoutBranch(issue.BranchName); err != nil {
@@ -89,35 +125,71 @@ func init() {
 	rootCmd.AddCommand(issueCmd)
 	issueCmd.Flags().BoolVarP(&url, "url", "u", false, "Copies the issue URL to your clipboard")
 	issueCmd.Flags().BoolVarP(&branch, "branch", "b", false, "Copies the branch name to your clipboard")
+	issueCmd.Flags().StringVar(&copyTemplate, "copy", "", "Copies a Go template over the issue to your clipboard, e.g. '{{.Identifier}}: {{.Title}}'")
+	issueCmd.MarkFlagsMutuallyExclusive("url", "branch", "copy")
 	issueCmd.Flags().BoolVarP(&checkout, "checkout", "c", false, "Creates a new branch in the cwd using the branch name from linear")
 	issueCmd.Flags().BoolVarP(&description, "verbose", "v", false, "Prints the issue description")
+	issueCmd.Flags().BoolVarP(&openIssue, "open", "o", false, "Opens the issue in your browser")
+	issueCmd.Flags().BoolVar(&showTree, "tree", false, "Shows the issue's parents and sub-issues as a tree")
+	issueCmd.Flags().BoolVar(&children, "children", false, "Lists the issue's sub-issues as a table")
 }
 
-func fetchIssue(issueID string) (*generated.IssueIssue, error) {
-	apiKey := viper.GetString("api_key")
-	if apiKey == "" {
-		return nil, fmt.Errorf("no API key found. Please run 'quick-branch auth' first")
+// renderMarkdown renders a description prettily for the terminal, falling
+// back to the plain text.
+func renderMarkdown(text string) string {
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+	)
+	if err != nil {
+		return text + "\n"
 	}
-	httpClient := &http.Client{
-		Transport: &authorizedTransport{
-			apiKey: apiKey,
-			base:   http.DefaultTransport,
-		},
+	out, err := renderer.Render(text)
+	if err != nil {
+		return text + "\n"
 	}
+	return out
+}
 
-	graphqlClient := graphql.NewClient("https://api.linear.app/graphql", httpClient)
+func fetchIssue(ctx context.Context, issueID string) (*generated.IssueIssue, error) {
+	issue, _, err := cached("issue-"+strings.ToUpper(issueID), func() (generated.IssueIssue, error) {
+		graphqlClient, err := newGraphQLClient()
+		if err != nil {
+			return generated.IssueIssue{}, err
+		}
 
-	ctx := context.Background()
-	response, err := generated.Issue(ctx, graphqlClient, issueID)
+		response, err := generated.Issue(ctx, graphqlClient, issueID)
+		if err != nil {
+			return generated.IssueIssue{}, issueError(err, issueID)
+		}
+		return response.Issue, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return &issue, nil
+}
 
-	return &response.Issue, nil
+// renderCopyTemplate executes a --copy template with the issue as its data,
+// so fields are named as in the GraphQL query: .Identifier, .Title, .Url,
+// .BranchName, .State.Name, .Team.Key.
+func renderCopyTemplate(text string, issue *generated.IssueIssue) (string, error) {
+	tmpl, err := template.New("copy").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid --copy template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, issue); err != nil {
+		return "", fmt.Errorf("invalid --copy template: %w", err)
+	}
+	return b.String(), nil
 }
 
 func checkoutBranch(branchName string) error {
-	err := exec.Command("git", "switch", "-c", branchName).Run()
+	args := []string{"switch", "-c", branchName}
+	if base := viper.GetString("branch.base"); base != "" {
+		args = append(args, base)
+	}
+	err := exec.Command("git", args...).Run()
 	if err != nil {
 		return err
 	}
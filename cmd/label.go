@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Add or remove labels on an issue",
+	Long: `label changes an issue's labels. Labels are found by name among the
+issue's team labels and the workspace labels; a label inside a group can be
+named on its own or as "Group/Label".`,
+}
+
+var labelAddCmd = &cobra.Command{
+	Use:   "add <issueID> <label>...",
+	Short: "Add labels to an issue",
+	Args:  cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, client, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		issue, err := fetchIssue(ctx, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		labels, err := fetchLabels(ctx, client)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var names []string
+		for _, l := range labels {
+			if !l.IsGroup && (l.Team == nil || l.Team.Id == issue.Team.Id) {
+				names = append(names, labelFieldsName(l))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		issueID := args[0]
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		resp, err := generated.Issue(ctx, client, issueID)
+		if err != nil {
+			return issueError(err, issueID)
+		}
+		issue := resp.Issue
+		labels, err := fetchLabels(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		// Resolve every name first, so a typo changes nothing.
+		current := issueLabels(issue.Labels.Nodes)
+		var toAdd []generated.LabelFields
+		for _, name := range args[1:] {
+			label, ok := findLabel(labels, name, issue.Team.Id)
+			if !ok {
+				return fmt.Errorf("no label named %q for team %s", name, issue.Team.Key)
+			}
+			if hasLabel(current, label.Id) {
+				fmt.Printf("%s already has label %s\n", issue.Identifier, labelFieldsName(label))
+				continue
+			}
+			toAdd = append(toAdd, label)
+		}
+
+		// Labels added before a failure are still added.
+		defer invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+		var added []string
+		var result []generated.IssueLabelSummary
+		for _, label := range toAdd {
+			mutation, err := generated.IssueAddLabel(ctx, client, issue.Id, label.Id)
+			if err != nil {
+				return issueError(err, issueID)
+			}
+			if mutation.IssueAddLabel.Issue != nil {
+				result = issueLabels(mutation.IssueAddLabel.Issue.Labels.Nodes)
+			}
+			added = append(added, labelFieldsName(label))
+		}
+		if len(added) > 0 {
+			fmt.Printf("Success! Added %s to %v\n", strings.Join(added, ", "), issue.Title)
+			fmt.Println("Labels:", renderLabels(result))
+		}
+		return nil
+	},
+}
+
+var labelRemoveCmd = &cobra.Command{
+	Use:     "remove <issueID> <label>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove labels from an issue",
+	Args:    cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, _, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		issue, err := fetchIssue(ctx, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var names []string
+		for _, l := range issueLabels(issue.Labels.Nodes) {
+			names = append(names, labelName(l))
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		issueID := args[0]
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		resp, err := generated.Issue(ctx, client, issueID)
+		if err != nil {
+			return issueError(err, issueID)
+		}
+		issue := resp.Issue
+
+		current := issueLabels(issue.Labels.Nodes)
+		var toRemove []generated.IssueLabelSummary
+		for _, name := range args[1:] {
+			var label *generated.IssueLabelSummary
+			for i, l := range current {
+				if strings.EqualFold(l.Name, name) || strings.EqualFold(labelName(l), name) {
+					label = &current[i]
+					break
+				}
+			}
+			if label == nil {
+				return fmt.Errorf("%s has no label named %q", issue.Identifier, name)
+			}
+			toRemove = append(toRemove, *label)
+		}
+
+		defer invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+		var removed []string
+		result := current
+		for _, label := range toRemove {
+			mutation, err := generated.IssueRemoveLabel(ctx, client, issue.Id, label.Id)
+			if err != nil {
+				return issueError(err, issueID)
+			}
+			if mutation.IssueRemoveLabel.Issue != nil {
+				result = issueLabels(mutation.IssueRemoveLabel.Issue.Labels.Nodes)
+			}
+			removed = append(removed, labelName(label))
+		}
+		fmt.Printf("Success! Removed %s from %v\n", strings.Join(removed, ", "), issue.Title)
+		fmt.Println("Labels:", renderLabels(result))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(labelCmd)
+	labelCmd.AddCommand(labelAddCmd, labelRemoveCmd)
+}
+
+// labelNode is the shape genqlient generates for every connection node that
+// only spreads IssueLabelSummary.
+type labelNode = struct {
+	generated.IssueLabelSummary `json:"-"`
+}
+
+// issueLabels unwraps the label nodes of any query that selects
+// IssueLabelSummary.
+func issueLabels[N ~labelNode](nodes []N) []generated.IssueLabelSummary {
+	labels := make([]generated.IssueLabelSummary, len(nodes))
+	for i, n := range nodes {
+		labels[i] = labelNode(n).IssueLabelSummary
+	}
+	return labels
+}
+
+func hasLabel(labels []generated.IssueLabelSummary, id string) bool {
+	for _, l := range labels {
+		if l.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// labelName is a label's name, prefixed with its group if it has one.
+func labelName(l generated.IssueLabelSummary) string {
+	if l.Parent != nil {
+		return l.Parent.Name + "/" + l.Name
+	}
+	return l.Name
+}
+
+func labelFieldsName(l generated.LabelFields) string {
+	if l.Parent != nil {
+		return l.Parent.Name + "/" + l.Name
+	}
+	return l.Name
+}
+
+// renderLabels shows labels in their Linear colors.
+func renderLabels(labels []generated.IssueLabelSummary) string {
+	if len(labels) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = lipgloss.NewStyle().Foreground(lipgloss.Color(l.Color)).Render(labelName(l))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fitLabels renders as many labels as fit in width columns and counts the
+// rest, e.g. "bug, backend +2".
+func fitLabels(labels []generated.IssueLabelSummary, width int) string {
+	used := 0
+	var parts []string
+	for i, l := range labels {
+		name := labelName(l)
+		more := ""
+		if i < len(labels)-1 {
+			more = fmt.Sprintf(" +%d", len(labels)-i-1)
+		}
+		sep := 0
+		if len(parts) > 0 {
+			sep = 2
+		}
+		if used+sep+lipgloss.Width(name)+lipgloss.Width(more) > width {
+			rest := fmt.Sprintf("+%d", len(labels)-i)
+			if len(parts) > 0 {
+				return strings.Join(parts, ", ") + " " + rest
+			}
+			return rest
+		}
+		used += sep + lipgloss.Width(name)
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color(l.Color)).Render(name))
+	}
+	return strings.Join(parts, ", ")
+}
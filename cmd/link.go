@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var (
+	linkTitle    string
+	linkGitHubPR string
+	linkGitLabMR string
+)
+
+var (
+	// gitHubPRPath matches /owner/repo/pull/123 on github.com or GitHub
+	// Enterprise.
+	gitHubPRPath = regexp.MustCompile(`^/[^/]+/[^/]+/pull/\d+/?$`)
+	// gitLabMRPath matches /group/sub/project/-/merge_requests/123 on any
+	// GitLab host.
+	gitLabMRPath = regexp.MustCompile(`^/(.+)/-/merge_requests/(\d+)/?$`)
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <issueID> [url]",
+	Short: "Attach a link, pull request or merge request to an issue",
+	Long: `link attaches a URL to an issue so it shows up in Linear and in
+'issue -v'. Use --github-pr or --gitlab-mr instead of a plain URL to link a
+pull or merge request, including ones on self-hosted servers without a Linear
+integration.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if linkGitHubPR != "" && linkGitLabMR != "" {
+			return fmt.Errorf("use either --github-pr or --gitlab-mr, not both")
+		}
+		if linkGitHubPR != "" || linkGitLabMR != "" {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		issueID := args[0]
+		var title *string
+		if linkTitle != "" {
+			title = &linkTitle
+		}
+
+		// Check the URL before any request is made.
+		var gitLabPath string
+		var gitLabNumber int
+		switch {
+		case linkGitHubPR != "":
+			u, err := parseLinkURL(linkGitHubPR)
+			if err != nil {
+				return err
+			}
+			if !gitHubPRPath.MatchString(u.Path) {
+				return fmt.Errorf("%s is not a pull request URL (…/owner/repo/pull/123)", linkGitHubPR)
+			}
+		case linkGitLabMR != "":
+			u, err := parseLinkURL(linkGitLabMR)
+			if err != nil {
+				return err
+			}
+			m := gitLabMRPath.FindStringSubmatch(u.Path)
+			if m == nil {
+				return fmt.Errorf("%s is not a merge request URL (…/group/project/-/merge_requests/123)", linkGitLabMR)
+			}
+			gitLabPath = m[1]
+			gitLabNumber, _ = strconv.Atoi(m[2])
+		default:
+			if _, err := parseLinkURL(args[1]); err != nil {
+				return err
+			}
+		}
+
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		var payload generated.AttachmentPayloadFields
+		switch {
+		case linkGitHubPR != "":
+			var resp *generated.AttachmentLinkGitHubPRResponse
+			resp, err = generated.AttachmentLinkGitHubPR(ctx, client, issueID, linkGitHubPR, title)
+			if err == nil {
+				payload = resp.AttachmentLinkGitHubPR.AttachmentPayloadFields
+			}
+		case linkGitLabMR != "":
+			var resp *generated.AttachmentLinkGitLabMRResponse
+			resp, err = generated.AttachmentLinkGitLabMR(ctx, client, issueID, linkGitLabMR, float64(gitLabNumber), gitLabPath, title)
+			if err == nil {
+				payload = resp.AttachmentLinkGitLabMR.AttachmentPayloadFields
+			}
+		default:
+			var resp *generated.AttachmentLinkURLResponse
+			resp, err = generated.AttachmentLinkURL(ctx, client, issueID, args[1], title)
+			if err == nil {
+				payload = resp.AttachmentLinkURL.AttachmentPayloadFields
+			}
+		}
+		if err != nil {
+			return issueError(err, issueID)
+		}
+		linked := payload.Attachment.Issue
+		invalidateIssueCache(issueID, linked.Id, linked.Identifier)
+		if !payload.Success {
+			return fmt.Errorf("link could not be attached to %s", issueID)
+		}
+		fmt.Printf("Success! Linked %s to %s\n", payload.Attachment.Title, issueID)
+		fmt.Println(payload.Attachment.Url)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+
+	linkCmd.Flags().StringVar(&linkTitle, "title", "", "Title shown for the link (default: chosen by Linear)")
+	linkCmd.Flags().StringVar(&linkGitHubPR, "github-pr", "", "Link a GitHub pull request by URL")
+	linkCmd.Flags().StringVar(&linkGitLabMR, "gitlab-mr", "", "Link a GitLab merge request by URL")
+}
+
+// parseLinkURL checks that s is an absolute http(s) URL.
+func parseLinkURL(s string) (*neturl.URL, error) {
+	u, err := neturl.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", s)
+	}
+	return u, nil
+}
@@ -5,11 +5,14 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/charmbracelet/huh"
@@ -21,6 +24,13 @@ import (
 	"golang.org/x/term"
 )
 
+var (
+	showLabels  bool
+	listCycle   string
+	listProject string
+	openList    bool
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List Linear issues based on your saved filters",
@@ -29,7 +39,7 @@ var listCmd = &cobra.Command{
 		// assigneeFilter := viper.GetString("list.assignee_filter")
 		// fmt.Printf("Fetching %s issues for team \"%s\"...\n\n", assigneeFilter, teamName)
 
-		resp, err := fetchIssues()
+		resp, fetchedAt, err := fetchIssues(cmd.Context())
 		if err != nil {
 			return err
 		}
@@ -38,84 +48,151 @@ var listCmd = &cobra.Command{
 			fmt.Println("No issues found.")
 			return nil
 		}
-		termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
-		if err != nil || termWidth == 0 {
-			termWidth = 100
+		if openList {
+			return pickAndOpen(resp.Issues.Nodes)
 		}
-
-		// Width(n) in lipgloss sets the content area only — padding is added on top.
-		// All cells use Padding(0, 1), so each column's rendered width = contentW + 2.
-		// Total = (p+2) + (t+2) + (title+2) + (s+2) + 5 borders = p+t+title+s+13
-		const (
-			priorityW = 6  // block chars (▄▆█) render as 2 cols each in most terminals
-			ticketW   = 12 // len("SWEAT-1009")=10, +2 so word-wrap at hyphen can't trigger
-			stateW    = 13 // len("In Progress")=11, +2 buffer
-			fixedW    = (priorityW + 2) + (ticketW + 2) + (stateW + 2) + 5
-		)
-
-		// Truncate titles so the table never exceeds the terminal width.
-		// We do NOT pin the title column width in StyleFunc — pinning causes
-		// lipgloss to word-wrap content that's even 1 display-column over the
-		// limit (common with East-Asian-width ambiguous chars like curly quotes).
-		// Without a pinned width, lipgloss renders title cells at natural content
-		// width with no wrapping; the column auto-sizes to the widest cell.
-		titleMaxW := termWidth - fixedW - 2
-		if titleMaxW < 10 {
-			titleMaxW = 10
-		}
-
-		rowCount := len(resp.Issues.Nodes)
-
-		var (
-			header = lipgloss.Color("#957FB8")
-			border = lipgloss.Color("#54546D")
-			text   = lipgloss.Color("#DCD7BA")
-
-			headerStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(header).Bold(true).Align(lipgloss.Center)
-			cellStyle   = lipgloss.NewStyle().Padding(0, 1).PaddingBottom(1).Foreground(text)
-			lastRow     = cellStyle.PaddingBottom(0).Foreground(text)
-		)
-
-		t := table.New().
-			Border(lipgloss.NormalBorder()).
-			BorderStyle(lipgloss.NewStyle().Foreground(border)).
-			StyleFunc(func(row, col int) lipgloss.Style {
-				var base lipgloss.Style
-				switch {
-				case row == table.HeaderRow:
-					base = headerStyle
-				case row == rowCount-1:
-					base = lastRow
-				default:
-					base = cellStyle
-				}
-				// Pin fixed columns; leave title (col 2) unpinned so it
-				// auto-sizes to content without word-wrapping.
-				switch col {
-				case 0:
-					return base.Width(priorityW)
-				case 1:
-					return base.Width(ticketW)
-				case 3:
-					return base.Width(stateW)
-				}
-				return base
-			}).
-			Headers("◌", "ID", "TITLE", "STATE")
-
-		for _, issue := range resp.Issues.Nodes {
-			t.Row(
-				getPriorityDisplay(issue.Priority),
-				issue.Identifier,
-				truncate(issue.Title, titleMaxW),
-				issue.State.Name,
-			)
+		printIssueTable(resp.Issues.Nodes)
+		if time.Since(fetchedAt) >= time.Minute {
+			fmt.Println(lipgloss.NewStyle().Foreground(tableBorder).Render(cachedAgo(fetchedAt) + " · --refresh to update"))
 		}
-		fmt.Println(t)
 		return nil
 	},
 }
 
+// printIssueTable prints issues as the list command's table, sized to the
+// terminal.
+func printIssueTable(issues []generated.FilteredIssuesIssuesIssueConnectionNodesIssue) {
+	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || termWidth == 0 {
+		termWidth = 100
+	}
+
+	// Width(n) in lipgloss sets the content area only — padding is added on top.
+	// All cells use Padding(0, 1), so each column's rendered width = contentW + 2.
+	// Total = (p+2) + (t+2) + (title+2) + (s+2) + 5 borders = p+t+title+s+13
+	const (
+		priorityW = 6  // block chars (▄▆█) render as 2 cols each in most terminals
+		ticketW   = 12 // len("SWEAT-1009")=10, +2 so word-wrap at hyphen can't trigger
+		stateW    = 13 // len("In Progress")=11, +2 buffer
+		labelsW   = 20 // only with --labels
+		fixedW    = (priorityW + 2) + (ticketW + 2) + (stateW + 2) + 5
+	)
+
+	// Truncate titles so the table never exceeds the terminal width.
+	// We do NOT pin the title column width in StyleFunc — pinning causes
+	// lipgloss to word-wrap content that's even 1 display-column over the
+	// limit (common with East-Asian-width ambiguous chars like curly quotes).
+	// Without a pinned width, lipgloss renders title cells at natural content
+	// width with no wrapping; the column auto-sizes to the widest cell.
+	titleMaxW := termWidth - fixedW - 2
+	if showLabels {
+		titleMaxW -= labelsW + 3
+	}
+	if titleMaxW < 10 {
+		titleMaxW = 10
+	}
+
+	rowCount := len(issues)
+
+	var (
+		headerStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(tableHeader).Bold(true).Align(lipgloss.Center)
+		cellStyle   = lipgloss.NewStyle().Padding(0, 1).PaddingBottom(1).Foreground(tableText)
+		lastRow     = cellStyle.PaddingBottom(0).Foreground(tableText)
+	)
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(tableBorder)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			var base lipgloss.Style
+			switch {
+			case row == table.HeaderRow:
+				base = headerStyle
+			case row == rowCount-1:
+				base = lastRow
+			default:
+				base = cellStyle
+			}
+			// Pin fixed columns; leave title (col 2) unpinned so it
+			// auto-sizes to content without word-wrapping.
+			switch col {
+			case 0:
+				return base.Width(priorityW)
+			case 1:
+				return base.Width(ticketW)
+			case 3:
+				return base.Width(stateW)
+			case 4:
+				return base.Width(labelsW)
+			}
+			return base
+		})
+	headers := []string{"◌", "ID", "TITLE", "STATE"}
+	if showLabels {
+		headers = append(headers, "LABELS")
+	}
+	t.Headers(headers...)
+
+	for _, issue := range issues {
+		row := []string{
+			getPriorityDisplay(issue.Priority),
+			issue.Identifier,
+			truncate(issue.Title, titleMaxW),
+			issue.State.Name,
+		}
+		if showLabels {
+			// Leave slack: a pinned column word-wraps content right at its width.
+			row = append(row, fitLabels(issueLabels(issue.Labels.Nodes), labelsW-2))
+		}
+		t.Row(row...)
+	}
+	fmt.Println(t)
+}
+
+// pickAndOpen lets the user choose one of issues and opens it in the
+// browser.
+func pickAndOpen(issues []generated.FilteredIssuesIssuesIssueConnectionNodesIssue) error {
+	opts := make([]huh.Option[string], len(issues))
+	for i, issue := range issues {
+		opts[i] = huh.NewOption(issue.Identifier+"  "+issue.Title, issue.Url)
+	}
+	var target string
+	err := huh.NewSelect[string]().
+		Title("Open which issue?").
+		Options(opts...).
+		Value(&target).
+		Run()
+	if err != nil {
+		return err
+	}
+	openURL(target)
+	return nil
+}
+
+// Table colors shared by every table quick-branch prints.
+var (
+	tableHeader = lipgloss.Color("#957FB8")
+	tableBorder = lipgloss.Color("#54546D")
+	tableText   = lipgloss.Color("#DCD7BA")
+)
+
+// compactTable returns a table styled like the issue list, without the
+// spacing between rows, for the smaller tables other commands print.
+func compactTable(headers ...string) *table.Table {
+	headerStyle := lipgloss.NewStyle().Padding(0, 1).Foreground(tableHeader).Bold(true)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1).Foreground(tableText)
+	return table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(tableBorder)).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		}).
+		Headers(headers...)
+}
+
 func getPriorityDisplay(priority float64) string {
 	switch priority {
 	case 0:
@@ -137,35 +214,42 @@ var listSetupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Configure the filters used by the list command",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runSetupWizard()
+		return runSetupWizard(cmd.Context())
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.AddCommand(listSetupCmd)
+
+	listCmd.Flags().BoolVarP(&showLabels, "labels", "l", false, "Show each issue's labels in an extra column")
+	listCmd.Flags().StringVar(&listCycle, "cycle", "", "Only show issues in a cycle: current, next, previous or a cycle number")
+	listCmd.Flags().BoolVarP(&openList, "open", "o", false, "Pick one of the listed issues and open it in your browser")
+	listCmd.Flags().StringVar(&listProject, "project", "", "Only show issues in this project (name or part of it)")
 }
 
-func newGraphQLClient() graphql.Client {
-	httpClient := &http.Client{
-		Transport: &authorizedTransport{
-			apiKey: viper.GetString("api_key"),
-			base:   http.DefaultTransport,
-		},
+func newGraphQLClient() (graphql.Client, error) {
+	// Replayed fixtures need no credential.
+	if replayFixtures != "" {
+		return newLinearClient(&authorizedTransport{}), nil
 	}
-	return graphql.NewClient("https://api.linear.app/graphql", httpClient)
+	transport, err := activeTransport()
+	if err != nil {
+		return nil, err
+	}
+	return newLinearClient(transport), nil
 }
 
-func runSetupWizard() error {
-	if viper.GetString("api_key") == "" {
-		return fmt.Errorf("no API key found. Please run 'quick-branch auth' first")
+func runSetupWizard(ctx context.Context) error {
+	client, err := newGraphQLClient()
+	if err != nil {
+		return err
 	}
 
-	ctx := context.Background()
-	client := newGraphQLClient()
-
 	// Step 1: fetch teams and pick team + assignee filter
-	teamsResp, err := generated.ViewerTeams(ctx, client)
+	teamsResp, _, err := cached("teams", func() (*generated.ViewerTeamsResponse, error) {
+		return generated.ViewerTeams(ctx, client)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to fetch teams: %w", err)
 	}
@@ -201,11 +285,10 @@ func runSetupWizard() error {
 	}
 
 	// Step 2: fetch states for the chosen team and pick which to include
-	statesResp, err := generated.TeamStatesById(ctx, client, selectedTeamID)
+	allStates, err := fetchTeamStates(ctx, client, selectedTeamID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch states: %w", err)
 	}
-	allStates := statesResp.Team.States.Nodes
 
 	stateOpts := make([]huh.Option[string], len(allStates))
 	for i, s := range allStates {
@@ -244,12 +327,13 @@ func runSetupWizard() error {
 		}
 	}
 
-	viper.Set("list.team_id", selectedTeamID)
-	viper.Set("list.team_name", teamName)
-	viper.Set("list.assignee_filter", assigneeFilter)
-	viper.Set("list.state_ids", selectedStateIDs)
-
-	if err := saveConfig(); err != nil {
+	err = saveConfig(map[string]any{
+		"list.team_id":         selectedTeamID,
+		"list.team_name":       teamName,
+		"list.assignee_filter": assigneeFilter,
+		"list.state_ids":       selectedStateIDs,
+	})
+	if err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -258,10 +342,36 @@ func runSetupWizard() error {
 	return nil
 }
 
-func fetchIssues() (*generated.FilteredIssuesResponse, error) {
-	if viper.GetString("api_key") == "" {
-		return nil, fmt.Errorf("no API key found. Please run 'quick-branch auth' first")
+func fetchIssues(ctx context.Context) (*generated.FilteredIssuesResponse, time.Time, error) {
+	filter, err := listIssueFilter(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
 	}
+
+	// Different filters (e.g. per-repo teams) get their own cache entry.
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	sum := sha256.Sum256(key)
+
+	return cached("issues-"+hex.EncodeToString(sum[:8]), func() (*generated.FilteredIssuesResponse, error) {
+		client, err := newGraphQLClient()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := generated.FilteredIssues(ctx, client, filter)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("team or state in your list filters no longer exists (%w). Run 'quick-branch list setup' again", err)
+		}
+		return resp, err
+	})
+}
+
+// listIssueFilter builds the filter for the issues 'list' shows, from the
+// list.* config and the --cycle and --project flags.
+func listIssueFilter(ctx context.Context) (*generated.IssueFilter, error) {
 	teamID := viper.GetString("list.team_id")
 	if teamID == "" {
 		return nil, fmt.Errorf("list not configured. Please run 'quick-branch list setup' first")
@@ -272,9 +382,42 @@ func fetchIssues() (*generated.FilteredIssuesResponse, error) {
 		viper.GetStringSlice("list.state_ids"),
 		viper.GetString("list.assignee_filter"),
 	)
+	if listCycle != "" {
+		cycle, err := cycleFilter(listCycle)
+		if err != nil {
+			return nil, err
+		}
+		filter.Cycle = cycle
+	}
+	if listProject != "" {
+		client, err := newGraphQLClient()
+		if err != nil {
+			return nil, err
+		}
+		project, err := resolveProject(ctx, client, listProject)
+		if err != nil {
+			return nil, err
+		}
+		filter.Project = &generated.NullableProjectFilter{Id: &generated.IDComparator{Eq: &project.Id}}
+	}
+	return filter, nil
+}
 
-	ctx := context.Background()
-	return generated.FilteredIssues(ctx, newGraphQLClient(), filter)
+// fetchTeamStates returns a team's workflow states, from the cache when fresh.
+func fetchTeamStates(ctx context.Context, client graphql.Client, teamID string) ([]generated.WorkflowStateFields, error) {
+	states, _, err := cached("states-"+teamID, func() ([]generated.WorkflowStateFields, error) {
+		response, err := generated.TeamStatesById(ctx, client, teamID)
+		if err != nil {
+			return nil, err
+		}
+		nodes := response.Team.States.Nodes
+		states := make([]generated.WorkflowStateFields, len(nodes))
+		for i, n := range nodes {
+			states[i] = n.WorkflowStateFields
+		}
+		return states, nil
+	})
+	return states, err
 }
 
 func buildIssueFilter(teamID string, stateIDs []string, assigneeFilter string) *generated.IssueFilter {
@@ -314,16 +457,3 @@ func truncate(s string, maxLen int) string {
 	}
 	return "…"
 }
-
-func saveConfig() error {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
-	}
-	appConfigDir := configDir + "/quick-branch"
-	if err := os.MkdirAll(appConfigDir, 0o700); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-	viper.SetConfigFile(appConfigDir + "/config.yaml")
-	return viper.WriteConfig()
-}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:     "move <issueID> [state]",
+	Aliases: []string{"state"},
+	Short:   "Move an issue to another workflow state",
+	Long: `move changes an issue's workflow state. The state name is case-insensitive
+and may be shortened to any unambiguous prefix, e.g. "in r" for "In Review".
+Without a state, pick one from a list.`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, client, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		states, err := issueTeamStates(ctx, client, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		names := make([]string, len(states))
+		for i, s := range states {
+			names[i] = s.Name + "\t" + s.Type
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		issueID := args[0]
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		states, err := issueTeamStates(ctx, client, issueID)
+		if err != nil {
+			return err
+		}
+
+		var state generated.WorkflowStateFields
+		if len(args) == 2 {
+			state, err = matchState(states, args[1])
+		} else {
+			state, err = pickState(states, strings.ToUpper(issueID))
+		}
+		if err != nil {
+			return err
+		}
+
+		resp, err := generated.IssueUpdate(ctx, client, issueID, generated.IssueUpdateInput{StateId: &state.Id})
+		if err != nil {
+			return issueError(err, issueID)
+		}
+		issue := resp.IssueUpdate.Issue
+		if issue == nil {
+			invalidateIssueCache(issueID)
+			return fmt.Errorf("issue %s could not be updated", issueID)
+		}
+		invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+		fmt.Printf("Success! Updated %v to %v\n", issue.Title, issue.State.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+}
+
+// matchState resolves name against a team's states: an exact
+// (case-insensitive) match wins, otherwise name must be the prefix of
+// exactly one state.
+func matchState(states []generated.WorkflowStateFields, name string) (generated.WorkflowStateFields, error) {
+	if s, ok := findState(states, name); ok {
+		return s, nil
+	}
+	prefix := strings.ToLower(name)
+	var matches []generated.WorkflowStateFields
+	for _, s := range states {
+		if strings.HasPrefix(strings.ToLower(s.Name), prefix) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return generated.WorkflowStateFields{}, fmt.Errorf("no workflow state matches %q; states are %s", name, stateNames(states))
+	}
+	return generated.WorkflowStateFields{}, fmt.Errorf("%q matches several workflow states: %s", name, stateNames(matches))
+}
+
+func stateNames(states []generated.WorkflowStateFields) string {
+	names := make([]string, len(states))
+	for i, s := range states {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// pickState lets the user choose one of states interactively.
+func pickState(states []generated.WorkflowStateFields, issueID string) (generated.WorkflowStateFields, error) {
+	opts := make([]huh.Option[int], len(states))
+	for i, s := range states {
+		opts[i] = huh.NewOption(s.Name, i)
+	}
+	var choice int
+	err := huh.NewSelect[int]().
+		Title("Move " + issueID + " to").
+		Options(opts...).
+		Value(&choice).
+		Run()
+	if err != nil {
+		return generated.WorkflowStateFields{}, err
+	}
+	return states[choice], nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+func TestMatchState(t *testing.T) {
+	states := []generated.WorkflowStateFields{
+		{Id: "s1", Name: "Todo"},
+		{Id: "s2", Name: "In Progress"},
+		{Id: "s3", Name: "In Review"},
+		{Id: "s4", Name: "Done"},
+	}
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "done", want: "s4"},
+		{name: "IN PROGRESS", want: "s2"},
+		{name: "in p", want: "s2"},
+		{name: "t", want: "s1"},
+		{name: "in", wantErr: `"in" matches several workflow states: In Progress, In Review`},
+		{name: "blocked", wantErr: `no workflow state matches "blocked"; states are Todo, In Progress, In Review, Done`},
+	}
+	for _, tt := range tests {
+		got, err := matchState(states, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("matchState(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.Id != tt.want {
+			t.Errorf("matchState(%q) = %s, %v; want %s", tt.name, got.Id, err, tt.want)
+		}
+	}
+}
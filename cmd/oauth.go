@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	authTypeAPIKey = "api_key"
+	authTypeOAuth  = "oauth"
+
+	defaultAuthorizeURL = "https://linear.app/oauth/authorize"
+	defaultTokenURL     = "https://api.linear.app/oauth/token"
+	defaultRedirectPort = 53682
+	defaultOAuthScopes  = "read,write"
+
+	oauthLoginTimeout = 5 * time.Minute
+)
+
+// openAuthorizeURL sends the user to the authorize page; tests replace it
+// with a client that follows the redirect themselves.
+var openAuthorizeURL = openBrowser
+
+// oauthConfig describes the OAuth application and endpoints. Every field can
+// be overridden under the oauth.* config keys, which is how the flow is
+// pointed at a local stand-in server.
+type oauthConfig struct {
+	ClientID     string
+	ClientSecret string
+	AuthorizeURL string
+	TokenURL     string
+	RedirectPort int
+	Scopes       string
+}
+
+func loadOAuthConfig() oauthConfig {
+	cfg := oauthConfig{
+		ClientID:     viper.GetString("oauth.client_id"),
+		ClientSecret: viper.GetString("oauth.client_secret"),
+		AuthorizeURL: viper.GetString("oauth.authorize_url"),
+		TokenURL:     viper.GetString("oauth.token_url"),
+		RedirectPort: viper.GetInt("oauth.redirect_port"),
+		Scopes:       viper.GetString("oauth.scopes"),
+	}
+	if cfg.AuthorizeURL == "" {
+		cfg.AuthorizeURL = defaultAuthorizeURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = defaultTokenURL
+	}
+	if cfg.RedirectPort == 0 {
+		cfg.RedirectPort = defaultRedirectPort
+	}
+	if cfg.Scopes == "" {
+		cfg.Scopes = defaultOAuthScopes
+	}
+	return cfg
+}
+
+// oauthToken is what gets saved in the secret store when auth_type is oauth.
+type oauthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"expiry,omitzero"`
+	// Account is the viewer ID, recorded at login to scope the cache.
+	Account string `json:"account,omitempty"`
+}
+
+// expired reports whether the token is expired or about to be.
+func (t oauthToken) expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(time.Minute).After(t.Expiry)
+}
+
+// tokenResponse is the body returned by the token endpoint.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func requestToken(ctx context.Context, cfg oauthConfig, form neturl.Values) (oauthToken, error) {
+	form.Set("client_id", cfg.ClientID)
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return oauthToken{}, fmt.Errorf("invalid token response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if body.Error != "" {
+		return oauthToken{}, fmt.Errorf("%s: %s", body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	token := oauthToken{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		TokenType:    body.TokenType,
+		Scope:        body.Scope,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// oauthSource hands out access tokens, refreshing and re-saving them when
+// they expire.
+type oauthSource struct {
+	mu    sync.Mutex
+	cfg   oauthConfig
+	token oauthToken
+	store secretStore
+}
+
+func newOAuthSource(secret string, store secretStore) (*oauthSource, error) {
+	var token oauthToken
+	if err := json.Unmarshal([]byte(secret), &token); err != nil {
+		return nil, fmt.Errorf("stored OAuth token is invalid, please run 'quick-branch auth --oauth' again: %w", err)
+	}
+	return &oauthSource{cfg: loadOAuthConfig(), token: token, store: store}, nil
+}
+
+func (s *oauthSource) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.token.expired() {
+		return s.token.AccessToken, nil
+	}
+	if s.token.RefreshToken == "" {
+		return "", errors.New("OAuth token expired. Please run 'quick-branch auth --oauth' again")
+	}
+
+	refreshed, err := requestToken(ctx, s.cfg, neturl.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.token.RefreshToken},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh OAuth token: %w", err)
+	}
+	// Not every server rotates refresh tokens.
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = s.token.RefreshToken
+	}
+	refreshed.Account = s.token.Account
+	s.token = refreshed
+
+	if s.store != nil {
+		data, err := json.Marshal(refreshed)
+		if err != nil {
+			return "", err
+		}
+		if err := s.store.Set(string(data)); err != nil {
+			return "", fmt.Errorf("failed to save refreshed OAuth token: %w", err)
+		}
+	}
+	return refreshed.AccessToken, nil
+}
+
+// runOAuthLogin performs the authorization-code-with-PKCE flow: it listens on
+// a loopback port, sends the user to the authorize URL, and exchanges the
+// code it receives for a token.
+func runOAuthLogin(ctx context.Context, cfg oauthConfig) (oauthToken, error) {
+	if cfg.ClientID == "" {
+		return oauthToken{}, errors.New("no OAuth client ID. Pass --client-id or set oauth.client_id")
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return oauthToken{}, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.RedirectPort))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("failed to listen for the OAuth redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://localhost:%d/callback", listener.Addr().(*net.TCPAddr).Port)
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("state") != state:
+			res.err = errors.New("OAuth state mismatch")
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization denied: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = errors.New("no authorization code in redirect")
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "quick-branch is authorized. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL, err := neturl.Parse(cfg.AuthorizeURL)
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("invalid authorize URL: %w", err)
+	}
+	authURL.RawQuery = neturl.Values{
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {redirectURI},
+		"response_type":         {"code"},
+		"scope":                 {cfg.Scopes},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	fmt.Println("Opening your browser to authorize quick-branch. If it doesn't open, visit:")
+	fmt.Printf("\n  %s\n\n", authURL)
+	_ = openAuthorizeURL(authURL.String())
+
+	ctx, cancel := context.WithTimeout(ctx, oauthLoginTimeout)
+	defer cancel()
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return oauthToken{}, errors.New("timed out waiting for authorization")
+	}
+	if res.err != nil {
+		return oauthToken{}, res.err
+	}
+
+	return requestToken(ctx, cfg, neturl.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// fakeOAuthServer is a stand-in for Linear's authorize and token endpoints.
+// It issues one code, checks the PKCE verifier on exchange and hands out a
+// new access token for every refresh.
+type fakeOAuthServer struct {
+	*httptest.Server
+	t         *testing.T
+	challenge string
+	// badState makes the authorize endpoint redirect with the wrong state.
+	badState  bool
+	refreshes int
+	// authorizations lists the Authorization headers sent to /graphql.
+	authorizations []string
+}
+
+func newFakeOAuthServer(t *testing.T) *fakeOAuthServer {
+	f := &fakeOAuthServer{t: t}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != "client" || q.Get("code_challenge_method") != "S256" {
+			t.Errorf("unexpected authorize request: %s", r.URL.RawQuery)
+		}
+		f.challenge = q.Get("code_challenge")
+		state := q.Get("state")
+		if f.badState {
+			state = "forged"
+		}
+		target := q.Get("redirect_uri") + "?" + neturl.Values{"code": {"the-code"}, "state": {state}}.Encode()
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var body map[string]any
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "the-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+				body = map[string]any{"error": "invalid_grant", "error_description": "bad code or verifier"}
+				break
+			}
+			body = map[string]any{"access_token": "access-1", "refresh_token": "refresh-1", "expires_in": 3600}
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				body = map[string]any{"error": "invalid_grant", "error_description": "unknown refresh token"}
+				break
+			}
+			f.refreshes++
+			body = map[string]any{"access_token": "access-2", "expires_in": 3600}
+		default:
+			t.Errorf("unexpected grant_type %q", r.PostForm.Get("grant_type"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		f.authorizations = append(f.authorizations, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":{}}`)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOAuthServer) config() oauthConfig {
+	return oauthConfig{
+		ClientID:     "client",
+		AuthorizeURL: f.URL + "/authorize",
+		TokenURL:     f.URL + "/token",
+		Scopes:       "read",
+	}
+}
+
+// followAuthorizeURL replaces the browser: it visits the authorize URL and
+// follows the redirect to the loopback callback.
+func followAuthorizeURL(t *testing.T) {
+	old := openAuthorizeURL
+	openAuthorizeURL = func(target string) error {
+		go func() {
+			resp, err := http.Get(target)
+			if err != nil {
+				t.Errorf("visiting authorize URL: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		return nil
+	}
+	t.Cleanup(func() { openAuthorizeURL = old })
+}
+
+type memoryStore struct{ secret string }
+
+func (m *memoryStore) Get() (string, error)    { return m.secret, nil }
+func (m *memoryStore) Set(secret string) error { m.secret = secret; return nil }
+func (m *memoryStore) Delete() error           { m.secret = ""; return nil }
+func (m *memoryStore) String() string          { return "memory" }
+
+func TestOAuthLoginAndRefresh(t *testing.T) {
+	server := newFakeOAuthServer(t)
+	followAuthorizeURL(t)
+
+	token, err := runOAuthLogin(context.Background(), server.config())
+	if err != nil {
+		t.Fatalf("runOAuthLogin: %v", err)
+	}
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" || token.Expiry.IsZero() {
+		t.Fatalf("unexpected token %+v", token)
+	}
+
+	// Expire the token so the next use refreshes it.
+	token.Expiry = time.Now().Add(-time.Minute)
+	token.Account = "viewer-1"
+	store := &memoryStore{}
+	source := &oauthSource{cfg: server.config(), token: token, store: store}
+
+	access, err := source.accessToken(context.Background())
+	if err != nil {
+		t.Fatalf("accessToken: %v", err)
+	}
+	if access != "access-2" || server.refreshes != 1 {
+		t.Fatalf("got access token %q after %d refreshes, want access-2 after 1", access, server.refreshes)
+	}
+	var stored oauthToken
+	if err := json.Unmarshal([]byte(store.secret), &stored); err != nil {
+		t.Fatalf("refreshed token not saved: %v", err)
+	}
+	if stored.AccessToken != "access-2" || stored.RefreshToken != "refresh-1" {
+		t.Errorf("saved token %+v should keep the unrotated refresh token", stored)
+	}
+	if stored.Account != "viewer-1" {
+		t.Errorf("saved token %+v lost the account that scopes the cache", stored)
+	}
+
+	// A fresh token is reused without another refresh.
+	if _, err := source.accessToken(context.Background()); err != nil || server.refreshes != 1 {
+		t.Errorf("fresh token refreshed again (err %v, %d refreshes)", err, server.refreshes)
+	}
+}
+
+func TestOAuthLoginStateMismatch(t *testing.T) {
+	server := newFakeOAuthServer(t)
+	server.badState = true
+	followAuthorizeURL(t)
+
+	_, err := runOAuthLogin(context.Background(), server.config())
+	if err == nil || !strings.Contains(err.Error(), "state mismatch") {
+		t.Fatalf("runOAuthLogin error = %v, want state mismatch", err)
+	}
+}
+
+func TestOAuthRefreshesOncePerRun(t *testing.T) {
+	server := newFakeOAuthServer(t)
+	token, err := json.Marshal(oauthToken{
+		AccessToken:  "access-1",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memoryStore{secret: string(token)}
+	activeCred = &credential{secret: store.secret, source: store.String(), store: store}
+	viper.Set("auth_type", authTypeOAuth)
+	viper.Set("oauth.client_id", "client")
+	viper.Set("oauth.token_url", server.URL+"/token")
+	t.Cleanup(func() {
+		activeCred = nil
+		activeOAuth = nil
+		viper.Set("auth_type", "")
+		viper.Set("oauth.client_id", "")
+		viper.Set("oauth.token_url", "")
+	})
+
+	// Commands like start build a client per operation; each must reuse the
+	// refreshed token rather than refresh again with the rotated-out one.
+	for range 2 {
+		transport, err := activeTransport()
+		if err != nil {
+			t.Fatalf("activeTransport: %v", err)
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if server.refreshes != 1 {
+		t.Errorf("token refreshed %d times, want 1", server.refreshes)
+	}
+	for i, auth := range server.authorizations {
+		if auth != "Bearer access-2" {
+			t.Errorf("request %d sent %q, want the refreshed token", i+1, auth)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+var (
+	allProjects   bool
+	projectHealth string
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "View Linear projects and their milestones",
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects with their status, lead, target date and progress",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		projects, err := fetchProjects(cmd.Context(), client)
+		if err != nil {
+			return err
+		}
+
+		t := compactTable("NAME", "STATUS", "HEALTH", "LEAD", "TARGET", "PROGRESS")
+		shown := 0
+		for _, p := range projects {
+			if !allProjects && (p.Status.Type == "completed" || p.Status.Type == "canceled") {
+				continue
+			}
+			lead := "—"
+			if p.Lead != nil {
+				lead = p.Lead.Name
+			}
+			t.Row(
+				truncate(p.Name, 40),
+				projectStatus(p),
+				healthLabel(p.Health),
+				lead,
+				formatDate(p.TargetDate),
+				fmt.Sprintf("%.0f%%", p.Progress*100),
+			)
+			shown++
+		}
+		if shown == 0 {
+			fmt.Println("No projects found.")
+			return nil
+		}
+		fmt.Println(t)
+		return nil
+	},
+}
+
+var projectShowCmd = &cobra.Command{
+	Use:   "show <project>",
+	Short: "Show a project's status, milestones and issue breakdown",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		project, err := resolveProject(ctx, client, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		details, _, err := cached("project-"+project.Id, func() (generated.ProjectDetailsProject, error) {
+			response, err := generated.ProjectDetails(ctx, client, project.Id)
+			if err != nil {
+				return generated.ProjectDetailsProject{}, err
+			}
+			return response.Project, nil
+		})
+		if err != nil {
+			return err
+		}
+		printProject(details)
+		return nil
+	},
+}
+
+var projectUpdateCmd = &cobra.Command{
+	Use:   "update <project>",
+	Short: "Write and post a project update",
+	Long: `update opens $EDITOR with a draft project update listing the project's
+issues completed in the last week, in progress and blocked. Edit it or keep
+it as written, then save and quit to post it; an empty update is not posted.
+Without --health, pick the project's health after editing.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var health generated.ProjectUpdateHealthType
+		if projectHealth != "" {
+			h, err := parseHealth(projectHealth)
+			if err != nil {
+				return err
+			}
+			health = h
+		}
+
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+		project, err := resolveProject(ctx, client, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		draft, err := draftProjectUpdate(ctx, client, project)
+		if err != nil {
+			return err
+		}
+		body, err := editText("quick-branch-update-*.md", draft)
+		if err != nil {
+			return err
+		}
+		if body == "" {
+			fmt.Println("Update is empty; nothing posted.")
+			return nil
+		}
+
+		if health == "" {
+			health, err = pickHealth(project)
+			if err != nil {
+				return err
+			}
+		}
+
+		resp, err := generated.ProjectUpdateCreate(ctx, client, generated.ProjectUpdateCreateInput{
+			ProjectId: project.Id,
+			Body:      &body,
+			Health:    &health,
+		})
+		if err != nil {
+			return err
+		}
+		invalidateCache("project-"+project.Id, "projects")
+		if !resp.ProjectUpdateCreate.Success {
+			return fmt.Errorf("update to %s could not be posted", project.Name)
+		}
+		fmt.Printf("Success! Posted %s update to %s\n", healthLabel(&health), project.Name)
+		fmt.Println(resp.ProjectUpdateCreate.ProjectUpdate.Url)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectListCmd, projectShowCmd, projectUpdateCmd)
+
+	projectListCmd.Flags().BoolVarP(&allProjects, "all", "a", false, "Include completed and canceled projects")
+	projectUpdateCmd.Flags().StringVar(&projectHealth, "health", "", "Project health: onTrack, atRisk or offTrack")
+	projectUpdateCmd.RegisterFlagCompletionFunc("health", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, len(generated.AllProjectUpdateHealthType))
+		for i, h := range generated.AllProjectUpdateHealthType {
+			names[i] = string(h)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// fetchProjects returns the workspace's projects sorted by name, from the
+// cache when fresh.
+func fetchProjects(ctx context.Context, client graphql.Client) ([]generated.ProjectFields, error) {
+	projects, _, err := cached("projects", func() ([]generated.ProjectFields, error) {
+		projects, err := fetchPages(func(after *string) ([]generated.ProjectFields, generated.PageInfoFields, error) {
+			response, err := generated.Projects(ctx, client, after)
+			if err != nil {
+				return nil, generated.PageInfoFields{}, err
+			}
+			nodes := response.Projects.Nodes
+			projects := make([]generated.ProjectFields, len(nodes))
+			for i, n := range nodes {
+				projects[i] = n.ProjectFields
+			}
+			return projects, response.Projects.PageInfo.PageInfoFields, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(projects, func(i, j int) bool {
+			return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+		})
+		return projects, nil
+	})
+	return projects, err
+}
+
+// resolveProject finds a project by name or slug: an exact
+// (case-insensitive) match wins, otherwise name must appear in exactly one
+// project name.
+func resolveProject(ctx context.Context, client graphql.Client, name string) (generated.ProjectFields, error) {
+	projects, err := fetchProjects(ctx, client)
+	if err != nil {
+		return generated.ProjectFields{}, err
+	}
+	var matches []generated.ProjectFields
+	for _, p := range projects {
+		if strings.EqualFold(p.Name, name) || strings.EqualFold(p.SlugId, name) {
+			return p, nil
+		}
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(name)) {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return generated.ProjectFields{}, fmt.Errorf("no project matches %q", name)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, p := range matches {
+		names[i] = p.Name
+	}
+	return generated.ProjectFields{}, fmt.Errorf("%q matches several projects: %s", name, strings.Join(names, ", "))
+}
+
+func projectStatus(p generated.ProjectFields) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(p.Status.Color)).Render(p.Status.Name)
+}
+
+// healthLabel renders a project's health in traffic-light colors.
+func healthLabel(h *generated.ProjectUpdateHealthType) string {
+	if h == nil {
+		return "—"
+	}
+	switch *h {
+	case generated.ProjectUpdateHealthTypeOntrack:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#4CB782")).Render("On track")
+	case generated.ProjectUpdateHealthTypeAtrisk:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#F2C94C")).Render("At risk")
+	case generated.ProjectUpdateHealthTypeOfftrack:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#EB5757")).Render("Off track")
+	}
+	return string(*h)
+}
+
+// formatDate shortens a Linear date (2006-01-02) to "Jan 2", adding the year
+// when it isn't this year.
+func formatDate(date *string) string {
+	if date == nil || *date == "" {
+		return "—"
+	}
+	t, err := time.Parse(time.DateOnly, *date)
+	if err != nil {
+		return *date
+	}
+	if t.Year() != time.Now().Year() {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("Jan 2")
+}
+
+func printProject(p generated.ProjectDetailsProject) {
+	fmt.Println(headingStyle.Render(p.Name))
+
+	meta := []string{projectStatus(p.ProjectFields), healthLabel(p.Health)}
+	if p.Lead != nil {
+		meta = append(meta, "Lead: "+p.Lead.Name)
+	}
+	meta = append(meta, formatDate(p.StartDate)+" – "+formatDate(p.TargetDate))
+	fmt.Println(strings.Join(meta, mutedStyle.Render(" · ")))
+	fmt.Println(mutedStyle.Render(p.Url))
+	if p.Description != "" {
+		fmt.Print(renderMarkdown(p.Description))
+	} else {
+		fmt.Println()
+	}
+	fmt.Printf("Progress   %s\n\n", progressBar(p.Progress, 24))
+
+	// Issue counts per milestone, keyed by milestone id ("" for none).
+	type counts struct{ done, started, todo int }
+	byMilestone := make(map[string]*counts)
+	for _, i := range p.Issues.Nodes {
+		id := ""
+		if i.ProjectMilestone != nil {
+			id = i.ProjectMilestone.Id
+		}
+		c := byMilestone[id]
+		if c == nil {
+			c = &counts{}
+			byMilestone[id] = c
+		}
+		switch i.State.Type {
+		case "completed":
+			c.done++
+		case "started":
+			c.started++
+		case "canceled":
+		default:
+			c.todo++
+		}
+	}
+
+	milestones := p.ProjectMilestones.Nodes
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i].SortOrder < milestones[j].SortOrder })
+
+	t := compactTable("MILESTONE", "TARGET", "PROGRESS", "DONE", "IN PROGRESS", "TODO")
+	row := func(name, target, progress string, c *counts) {
+		if c == nil {
+			c = &counts{}
+		}
+		t.Row(name, target, progress, fmt.Sprint(c.done), fmt.Sprint(c.started), fmt.Sprint(c.todo))
+	}
+	for _, m := range milestones {
+		row(m.Name, formatDate(m.TargetDate), fmt.Sprintf("%.0f%%", m.Progress*100), byMilestone[m.Id])
+	}
+	if c := byMilestone[""]; c != nil || len(milestones) == 0 {
+		row("No milestone", "—", "—", c)
+	}
+	fmt.Println(t)
+}
+
+// parseHealth accepts a health value case-insensitively, with or without
+// separators ("atRisk", "at-risk", "at risk").
+func parseHealth(s string) (generated.ProjectUpdateHealthType, error) {
+	key := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(s))
+	for _, h := range generated.AllProjectUpdateHealthType {
+		if strings.ToLower(string(h)) == key {
+			return h, nil
+		}
+	}
+	return "", fmt.Errorf("invalid health %q; use onTrack, atRisk or offTrack", s)
+}
+
+// pickHealth asks for the update's health, starting from the project's
+// current one.
+func pickHealth(project generated.ProjectFields) (generated.ProjectUpdateHealthType, error) {
+	health := generated.ProjectUpdateHealthTypeOntrack
+	if project.Health != nil {
+		health = *project.Health
+	}
+	err := huh.NewSelect[generated.ProjectUpdateHealthType]().
+		Title("How is "+project.Name+" doing?").
+		Options(
+			huh.NewOption("On track", generated.ProjectUpdateHealthTypeOntrack),
+			huh.NewOption("At risk", generated.ProjectUpdateHealthTypeAtrisk),
+			huh.NewOption("Off track", generated.ProjectUpdateHealthTypeOfftrack),
+		).
+		Value(&health).
+		Run()
+	return health, err
+}
+
+// draftProjectUpdate fills the update template with the project's issues
+// completed in the last week, in progress and blocked.
+func draftProjectUpdate(ctx context.Context, client graphql.Client, project generated.ProjectFields) (string, error) {
+	inProject := &generated.NullableProjectFilter{Id: &generated.IDComparator{Eq: &project.Id}}
+	lastWeek, started, blocked := "-P1W", "started", true
+	sections := []struct {
+		title   string
+		filter  generated.IssueFilter
+		blocked bool
+	}{
+		{"Completed this week", generated.IssueFilter{
+			Project:     inProject,
+			CompletedAt: &generated.NullableDateComparator{Gte: &lastWeek},
+		}, false},
+		{"In progress", generated.IssueFilter{
+			Project: inProject,
+			State:   &generated.WorkflowStateFilter{Type: &generated.StringComparator{Eq: &started}},
+		}, false},
+		{"Blocked", generated.IssueFilter{
+			Project:               inProject,
+			HasBlockedByRelations: &generated.RelationExistsComparator{Eq: &blocked},
+			State:                 &generated.WorkflowStateFilter{Type: &generated.StringComparator{Nin: []string{"completed", "canceled"}}},
+		}, true},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- Update for %s. Edit it, then save and quit to post it.\n", project.Name)
+	b.WriteString("     Comments like this one are removed; delete everything to cancel. -->\n")
+	for _, section := range sections {
+		response, err := generated.ProjectIssues(ctx, client, section.filter)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		listed := 0
+		for _, i := range response.Issues.Nodes {
+			// The filter also matches issues whose blockers are all done.
+			var blockers []string
+			for _, r := range unfinishedBlockers(i.InverseRelations.Nodes) {
+				blockers = append(blockers, r.Identifier)
+			}
+			if section.blocked && len(blockers) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s %s", i.Identifier, i.Title)
+			if i.Assignee != nil {
+				fmt.Fprintf(&b, " (%s)", i.Assignee.Name)
+			}
+			if section.blocked {
+				fmt.Fprintf(&b, " — blocked by %s", strings.Join(blockers, ", "))
+			}
+			b.WriteString("\n")
+			listed++
+		}
+		if listed == 0 {
+			b.WriteString("- Nothing\n")
+		}
+	}
+	return b.String(), nil
+}
+
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->\n?`)
+
+// editText opens text in the user's editor and returns what they saved,
+// without HTML comments and surrounding blank space.
+func editText(pattern, text string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := runEditor(f.Name()); err != nil {
+		return "", err
+	}
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(htmlComment.ReplaceAllString(string(edited), "")), nil
+}
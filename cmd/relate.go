@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rangoons/quick-branch/internal/generated"
+	"github.com/spf13/cobra"
+)
+
+// relateTypes maps each relate verb to the relation Linear stores and
+// whether the two issues swap places, since Linear only knows "blocks".
+var relateTypes = map[string]struct {
+	kind    generated.IssueRelationType
+	inverse bool
+}{
+	"blocks":     {generated.IssueRelationTypeBlocks, false},
+	"blocked-by": {generated.IssueRelationTypeBlocks, true},
+	"related":    {generated.IssueRelationTypeRelated, false},
+	"duplicate":  {generated.IssueRelationTypeDuplicate, false},
+}
+
+var relateCmd = &cobra.Command{
+	Use:   "relate <issueID> blocks|blocked-by|related|duplicate <otherID>",
+	Short: "Link two issues with a relation",
+	Long: `relate records how two issues depend on each other:
+
+  quick-branch relate ABC-1 blocks ABC-2      ABC-2 can't start until ABC-1 is done
+  quick-branch relate ABC-1 blocked-by ABC-2  the other way round
+  quick-branch relate ABC-1 related ABC-2
+  quick-branch relate ABC-1 duplicate ABC-2   ABC-1 is a duplicate of ABC-2`,
+	Args: cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return relateVerbs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issueID, verb, otherID := args[0], strings.ToLower(args[1]), args[2]
+		rel, ok := relateTypes[verb]
+		if !ok {
+			return fmt.Errorf("unknown relation %q; use %s", args[1], strings.Join(relateVerbs(), ", "))
+		}
+		if strings.EqualFold(issueID, otherID) {
+			return fmt.Errorf("an issue can't be related to itself")
+		}
+		client, err := newGraphQLClient()
+		if err != nil {
+			return err
+		}
+
+		input := generated.IssueRelationCreateInput{IssueId: issueID, RelatedIssueId: otherID, Type: rel.kind}
+		if rel.inverse {
+			input.IssueId, input.RelatedIssueId = otherID, issueID
+		}
+		resp, err := generated.IssueRelationCreate(cmd.Context(), client, input)
+		if err != nil {
+			if !isNotFound(err) {
+				return err
+			}
+			// Linear doesn't say which issue is missing, so look at the first.
+			if _, lookupErr := fetchIssue(cmd.Context(), issueID); isNotFound(lookupErr) {
+				return lookupErr
+			}
+			return issueError(err, otherID)
+		}
+		r := resp.IssueRelationCreate.IssueRelation
+		invalidateIssueCache(issueID, otherID, r.Issue.Id, r.Issue.Identifier, r.RelatedIssue.Id, r.RelatedIssue.Identifier)
+		if !resp.IssueRelationCreate.Success {
+			return fmt.Errorf("relation between %s and %s could not be created", issueID, otherID)
+		}
+		fmt.Printf("Success! %s %s %s\n", strings.ToUpper(issueID), strings.ReplaceAll(verb, "-", " "), strings.ToUpper(otherID))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(relateCmd)
+}
+
+func relateVerbs() []string {
+	verbs := make([]string, 0, len(relateTypes))
+	for v := range relateTypes {
+		verbs = append(verbs, v)
+	}
+	sort.Strings(verbs)
+	return verbs
+}
+
+// relationNode and inverseRelationNode are the shapes genqlient generates
+// for connection nodes that only spread RelationFields or
+// InverseRelationFields.
+type relationNode = struct {
+	generated.RelationFields `json:"-"`
+}
+
+type inverseRelationNode = struct {
+	generated.InverseRelationFields `json:"-"`
+}
+
+// issueRelation is one of an issue's relations, described from its side.
+type issueRelation struct {
+	kind  string
+	issue generated.RelatedIssueFields
+}
+
+// relationKinds names relations from the issue's side and
+// inverseRelationKinds from the related issue's; relationOrder is the order
+// the issue view lists them in.
+var (
+	relationKinds = map[string]string{
+		"blocks":    "Blocks",
+		"duplicate": "Duplicate of",
+		"related":   "Related to",
+		"similar":   "Similar to",
+	}
+	inverseRelationKinds = map[string]string{
+		"blocks":    "Blocked by",
+		"duplicate": "Duplicated by",
+		"related":   "Related to",
+		"similar":   "Similar to",
+	}
+	relationOrder = []string{"Blocked by", "Blocks", "Duplicate of", "Duplicated by", "Related to", "Similar to"}
+)
+
+// issueRelations merges an issue's relations and inverse relations, naming
+// each from the issue's point of view: an inverse "blocks" is "Blocked by".
+func issueRelations[R ~relationNode, I ~inverseRelationNode](relations []R, inverse []I) []issueRelation {
+	var all []issueRelation
+	for _, r := range relations {
+		f := relationNode(r).RelationFields
+		all = append(all, issueRelation{relationKind(relationKinds, f.Type), f.RelatedIssue.RelatedIssueFields})
+	}
+	for _, r := range inverse {
+		f := inverseRelationNode(r).InverseRelationFields
+		all = append(all, issueRelation{relationKind(inverseRelationKinds, f.Type), f.Issue.RelatedIssueFields})
+	}
+	rank := func(kind string) int {
+		for i, k := range relationOrder {
+			if k == kind {
+				return i
+			}
+		}
+		return len(relationOrder)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return rank(all[i].kind) < rank(all[j].kind) })
+	return all
+}
+
+func relationKind(kinds map[string]string, relationType string) string {
+	if kind, ok := kinds[relationType]; ok {
+		return kind
+	}
+	return relationType
+}
+
+// unfinishedBlockers returns the issues blocking an issue that aren't done
+// or canceled yet.
+func unfinishedBlockers[I ~inverseRelationNode](inverse []I) []generated.RelatedIssueFields {
+	var blockers []generated.RelatedIssueFields
+	for _, r := range issueRelations([]relationNode(nil), inverse) {
+		if r.kind == "Blocked by" && r.issue.State.Type != "completed" && r.issue.State.Type != "canceled" {
+			blockers = append(blockers, r.issue)
+		}
+	}
+	return blockers
+}
+
+// printRelations lists relations under a "Relations:" heading, one per line
+// with the related issue's state in its Linear color.
+func printRelations(relations []issueRelation) {
+	if len(relations) == 0 {
+		return
+	}
+	fmt.Println("Relations:")
+	for _, r := range relations {
+		state := lipgloss.NewStyle().Foreground(lipgloss.Color(r.issue.State.Color)).Render(r.issue.State.Name)
+		fmt.Printf("  %-13s %s %s · %s\n", r.kind, r.issue.Identifier, truncate(r.issue.Title, 50), state)
+	}
+}
+
+// warnIfBlocked points out unfinished issues that block issueID.
+func warnIfBlocked(issueID string, blockers []generated.RelatedIssueFields) {
+	if len(blockers) == 0 {
+		return
+	}
+	fmt.Printf("Warning: %s is blocked by %s:\n", issueID, plural(len(blockers), "unfinished issue"))
+	for _, b := range blockers {
+		fmt.Printf("  %s %s (%s)\n", b.Identifier, truncate(b.Title, 50), b.State.Name)
+	}
+}
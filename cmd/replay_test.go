@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rangoons/quick-branch/internal/generated"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// runReplay runs quick-branch with args against the fixtures in
+// testdata/replay and returns what it printed.
+func runReplay(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := replayCommand(t, args...)
+	if err != nil {
+		t.Fatalf("quick-branch %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	if strings.Contains(out, "Error") {
+		t.Fatalf("quick-branch %s failed:\n%s", strings.Join(args, " "), out)
+	}
+	return out
+}
+
+// replayCommand is runReplay for commands that are expected to fail.
+func replayCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CACHE_HOME", home)
+	t.Setenv("QUICK_BRANCH_LIST_TEAM_ID", "t1")
+	resetFlags(rootCmd)
+	t.Cleanup(func() {
+		resetFlags(rootCmd)
+		noCache = false
+		activeCred = nil
+		activeOAuth = nil
+	})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+
+	rootCmd.SetArgs(append(args, "--replay-fixtures", "testdata/replay"))
+	err = rootCmd.Execute()
+	w.Close()
+	os.Stdout = stdout
+	return string(<-done), err
+}
+
+// resetFlags puts every flag of cmd and its subcommands back to its default,
+// since flag values outlive a single Execute.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// assertPrinted fails unless out contains every one of want.
+func assertPrinted(t *testing.T, out string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("output is missing %q:\n%s", w, out)
+		}
+	}
+}
+
+func TestReplayIssue(t *testing.T) {
+	out := runReplay(t, "issue", "ENG-1", "-v")
+	assertPrinted(t, out, "ENG-1", "Fix it", "Todo", "Fix login #42")
+}
+
+func TestReplayIssueTree(t *testing.T) {
+	out := runReplay(t, "issue", "ENG-1", "--tree")
+	assertPrinted(t, out, "ENG-1 Fix it", "ENG-20 Backend part", "ENG-30 Schema")
+}
+
+func TestReplayStart(t *testing.T) {
+	out := runReplay(t, "start", "ENG-1", "--status")
+	assertPrinted(t, out,
+		"Assigned Jane Doe to Fix it",
+		"Updated Fix it to In Progress",
+	)
+	// The blocker is pointed out before anything changes.
+	warning := strings.Index(out, "ENG-1 is blocked by 1 unfinished issue")
+	if warning < 0 || warning > strings.Index(out, "Assigned") {
+		t.Errorf("want the blocked warning before the assignment:\n%s", out)
+	}
+}
+
+func TestReplayStartDryRun(t *testing.T) {
+	// There are no fixtures for IssueBatchUpdate, so a mutation would fail.
+	out := runReplay(t, "start", "ENG-1", "--turbo", "--dry-run")
+	assertPrinted(t, out,
+		"unassigned, Todo → Jane Doe, In Progress",
+		"ENG-1 ⚠",
+		"ENG-1 is blocked by ENG-3",
+		"Dry run: no issues were changed.",
+	)
+}
+
+func TestReplayBulkSkipsByID(t *testing.T) {
+	// ENG-1 already has Bug, and every list result is fetched in one query.
+	out := runReplay(t, "bulk", "label", "add", "--from-list", "-l", "bug", "--dry-run")
+	assertPrinted(t, out, "Skipping ENG-1: already labelled Bug", "ENG-2", "+Bug")
+	if strings.Contains(out, "│ ENG-1") {
+		t.Errorf("ENG-1 is previewed although it has the label:\n%s", out)
+	}
+
+	// ENG-4's assignee only shares a name with @janed; ENG-5 is already theirs.
+	out = runReplay(t, "bulk", "assign", "ENG-4", "ENG-5", "--to", "@janed", "--dry-run")
+	assertPrinted(t, out, "Skipping ENG-5: already assigned to Jane Doe", "ENG-4", "Jane Doe → Jane Doe (@janed)")
+}
+
+func TestReplayList(t *testing.T) {
+	out := runReplay(t, "list")
+	assertPrinted(t, out, "ENG-1", "First thing", "ENG-2", "Second")
+}
+
+func TestReplayCycleListPages(t *testing.T) {
+	// The active cycle is only on the second page.
+	out := runReplay(t, "cycle", "list")
+	a, b, c := strings.Index(out, "Cycle 41"), strings.Index(out, "Cycle 42"), strings.Index(out, "Cycle 43")
+	if a < 0 || !(a < b && b < c) {
+		t.Errorf("want cycles 41, 42 and 43 in order:\n%s", out)
+	}
+}
+
+func TestReplayCycleIssuePages(t *testing.T) {
+	// The cycle's issues span two pages and it has no burndown history yet.
+	out := runReplay(t, "cycle")
+	assertPrinted(t, out, "Cycle 42", "ENG-10", "ENG-11", "ENG-12", "Scope      3 issues", "Remaining  2 issues")
+	if strings.Contains(out, "Burndown") {
+		t.Errorf("burndown drawn without any history:\n%s", out)
+	}
+}
+
+func TestReplayInboxPages(t *testing.T) {
+	// The inbox spans two pages; n3 is snoozed until next year.
+	out := runReplay(t, "inbox")
+	assertPrinted(t, out, "Fix login redirect", "Flaky deploy", "changed the status", "Assigned", "Comment", "Status")
+	if strings.Contains(out, "ENG-9") {
+		t.Errorf("snoozed notification shown:\n%s", out)
+	}
+}
+
+func TestReplayRelateNamesMissingIssue(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"relate", "ENG-1", "blocks", "ENG-999"}, "issue ENG-999 not found in your workspace"},
+		{[]string{"relate", "ENG-998", "blocks", "ENG-1"}, "issue ENG-998 not found in your workspace"},
+	}
+	for _, tt := range tests {
+		_, err := replayCommand(t, tt.args...)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("quick-branch %s: error = %v, want %q", strings.Join(tt.args, " "), err, tt.want)
+		}
+	}
+}
+
+func TestReplayLeavesCacheAlone(t *testing.T) {
+	// With a credential around, the cache would be usable.
+	t.Setenv("QUICK_BRANCH_API_KEY", "lin_api_test")
+	runReplay(t, "issue", "ENG-1")
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		var names bytes.Buffer
+		for _, e := range entries {
+			names.WriteString(e.Name() + " ")
+		}
+		t.Errorf("replay wrote to the cache: %s", names.String())
+	}
+}
+
+// echoTransport answers every request with the Authorization header it got,
+// the worst case for a recorder.
+type echoTransport struct{}
+
+func (echoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `{"data":{"viewer":{"id":"u1","name":"` + req.Header.Get("Authorization") + `"}}}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRecordScrubsOAuthToken(t *testing.T) {
+	dir := t.TempDir()
+	recordFixtures = dir
+	t.Cleanup(func() { recordFixtures = "" })
+
+	auth := &authorizedTransport{
+		oauth: &oauthSource{token: oauthToken{AccessToken: "oauth-secret", Expiry: time.Now().Add(time.Hour)}},
+		base:  echoTransport{},
+	}
+	if _, err := generated.Me(context.Background(), newLinearClient(auth)); err != nil {
+		t.Fatalf("Me: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "Me-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("got fixtures %v, want one for Me", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("oauth-secret")) {
+		t.Errorf("recorded fixture leaks the access token:\n%s", data)
+	}
+}
+
+// TestReplayFixturesMatchQueries keeps the fixtures honest: each must be
+// written the way --record-fixtures writes it, and answer exactly the fields
+// its operation selects in queries.graphql.
+func TestReplayFixturesMatchQueries(t *testing.T) {
+	schema, err := os.ReadFile("../schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := os.ReadFile("../queries.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, gqlErr := gqlparser.LoadQuery(
+		gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: string(schema)}),
+		string(queries),
+	)
+	if gqlErr != nil {
+		t.Fatal(gqlErr)
+	}
+
+	files, _ := filepath.Glob("testdata/replay/*.json")
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		variables, err := canonicalJSON(f.Variables)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if name := fixtureName(f.Operation, variables); name != filepath.Base(path) {
+			t.Errorf("%s: operation and variables hash to %s", path, name)
+		}
+		recorded, _ := json.MarshalIndent(f, "", "  ")
+		if !bytes.Equal(append(recorded, '\n'), data) {
+			t.Errorf("%s is not laid out the way the recorder writes fixtures", path)
+		}
+
+		op := doc.Operations.ForName(f.Operation)
+		if op == nil {
+			t.Errorf("%s: no operation %s in queries.graphql", path, f.Operation)
+			continue
+		}
+		var body struct{ Data any }
+		if err := json.Unmarshal(f.Body, &body); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		for _, problem := range selectionProblems(op.SelectionSet, body.Data, "data") {
+			t.Errorf("%s: %s", path, problem)
+		}
+	}
+}
+
+// selectionProblems lists where v, a response value, has fields that set
+// doesn't select or lacks fields that it does.
+func selectionProblems(set ast.SelectionSet, v any, path string) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		var problems []string
+		for i, e := range v {
+			problems = append(problems, selectionProblems(set, e, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return problems
+	case map[string]any:
+		fields := map[string]ast.SelectionSet{}
+		collectFields(set, fields)
+		var problems []string
+		for name, sub := range fields {
+			value, ok := v[name]
+			switch {
+			case !ok:
+				problems = append(problems, path+"."+name+" is selected but missing")
+			case len(sub) > 0:
+				problems = append(problems, selectionProblems(sub, value, path+"."+name)...)
+			}
+		}
+		for name := range v {
+			// genqlient asks for __typename wherever the type is abstract.
+			if _, ok := fields[name]; !ok && name != "__typename" {
+				problems = append(problems, path+"."+name+" is not selected")
+			}
+		}
+		sort.Strings(problems)
+		return problems
+	}
+	return []string{path + " should be an object"}
+}
+
+// collectFields flattens set, following fragments, into each response key's
+// merged sub-selection.
+func collectFields(set ast.SelectionSet, fields map[string]ast.SelectionSet) {
+	for _, s := range set {
+		switch s := s.(type) {
+		case *ast.Field:
+			fields[s.Alias] = append(fields[s.Alias], s.SelectionSet...)
+		case *ast.FragmentSpread:
+			collectFields(s.Definition.SelectionSet, fields)
+		case *ast.InlineFragment:
+			collectFields(s.SelectionSet, fields)
+		}
+	}
+}
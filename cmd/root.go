@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"io/fs"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/Khan/genqlient/graphql"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -34,7 +39,9 @@ update status to "In Progress", and checkout the branch in one command.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -45,7 +52,19 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.quick-branch.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is <user config dir>/quick-branch/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Read issues, teams and states from the local cache only")
+	rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "Ignore the local cache and fetch fresh data")
+	rootCmd.MarkFlagsMutuallyExclusive("offline", "refresh")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Log each Linear API request to stderr")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "Append full Linear API requests and responses to this file as JSON lines")
+	// Fixture flags are for building tests, so they stay out of --help.
+	rootCmd.PersistentFlags().StringVar(&recordFixtures, "record-fixtures", "", "Record Linear API responses as fixtures in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayFixtures, "replay-fixtures", "", "Serve Linear API responses from fixtures in this directory")
+	rootCmd.MarkFlagsMutuallyExclusive("record-fixtures", "replay-fixtures")
+	rootCmd.PersistentFlags().MarkHidden("record-fixtures")
+	rootCmd.PersistentFlags().MarkHidden("replay-fixtures")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", 30*time.Second, "Timeout for each Linear API request, including retries (0 for none)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -53,30 +72,70 @@ func init() {
 }
 
 func initializeConfig(cmd *cobra.Command) error {
-	// set up viper to use env vars
-	viper.SetEnvPrefix("quick-branch")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "*", "-", "*"))
+	// Fixtures must see every request and must not end up in the user's
+	// cache. This runs for shell completion too.
+	if recordFixtures != "" || replayFixtures != "" {
+		noCache = true
+	}
+
+	// set up viper to use env vars: list.team_id -> QUICK_BRANCH_LIST_TEAM_ID
+	viper.SetEnvPrefix("quick_branch")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 	viper.AutomaticEnv()
+	// AutomaticEnv only kicks in for explicit Get calls; binding the known
+	// keys makes env overrides of nested keys show up in AllSettings too.
+	for _, key := range configKeys {
+		if err := viper.BindEnv(key.name); err != nil {
+			return err
+		}
+	}
 
-	// Handle the config file
-	if cfgFile != "" {
-		// use config from the flag
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Use platform-specific config directory
-		configDir, err := os.UserConfigDir()
-		cobra.CheckErr(err)
-
-		viper.AddConfigPath(configDir + "/quick-branch")
-		viper.SetConfigName("config")
-		viper.SetConfigType("yaml")
+	err := loadConfigFile()
+	if errors.Is(err, fs.ErrNotExist) && createsConfig(cmd) {
+		// config set and edit create a missing --config file on first write.
+		return mergeRepoConfig()
+	}
+	return err
+}
+
+// createsConfig reports whether cmd writes the config file, and so may be
+// pointed at one that doesn't exist yet.
+func createsConfig(cmd *cobra.Command) bool {
+	return cmd == configSetCmd || cmd == configEditCmd || cmd == configPathCmd
+}
+
+// completionClient prepares a Linear client for shell completion, which
+// runs without the root command's PersistentPreRunE.
+func completionClient(cmd *cobra.Command) (context.Context, graphql.Client, error) {
+	if err := initializeConfig(cmd); err != nil {
+		return nil, nil, err
+	}
+	client, err := newGraphQLClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return ctx, client, nil
+}
+
+// loadConfigFile (re)reads the user config file into viper and merges the
+// repository config over it.
+func loadConfigFile() error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
 	}
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
 
 	if err := viper.ReadInConfig(); err != nil {
-		var configFileNotFoundError viper.ConfigFileNotFoundError
-		if !errors.As(err, &configFileNotFoundError) {
+		// An explicit --config must exist; the default one is optional.
+		if cfgFile != "" || !errors.Is(err, fs.ErrNotExist) {
 			return err
 		}
 	}
-	return nil
+	return mergeRepoConfig()
 }
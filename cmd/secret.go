@@ -0,0 +1,429 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+)
+
+// Values accepted by the credential_store config key. Anything starting with
+// "exec:" names a credential helper command instead.
+const (
+	storeKeyring = "keyring"
+	storeFile    = "file"
+	storeConfig  = "config"
+	storeExec    = "exec:"
+
+	keyringService = "quick-branch"
+	keyringUser    = "api_key"
+)
+
+var (
+	errNoAPIKey       = errors.New("no API key found. Please run 'quick-branch auth' first")
+	errSecretNotFound = errors.New("secret not found")
+)
+
+// secretStore persists the API key somewhere other than viper. The config file
+// only records which store is in use (see String).
+type secretStore interface {
+	Get() (string, error)
+	Set(secret string) error
+	Delete() error
+	// String returns the reference saved under credential_store.
+	String() string
+}
+
+// newSecretStore returns the store named by ref.
+func newSecretStore(ref string) (secretStore, error) {
+	switch {
+	case ref == storeKeyring:
+		return keyringStore{}, nil
+	case ref == storeFile:
+		path, err := credentialsFilePath()
+		if err != nil {
+			return nil, err
+		}
+		return &encryptedFileStore{path: path}, nil
+	case ref == storeConfig:
+		return configStore{}, nil
+	case strings.HasPrefix(ref, storeExec):
+		args := strings.Fields(strings.TrimPrefix(ref, storeExec))
+		if len(args) == 0 {
+			return nil, fmt.Errorf("credential helper %q has no command", ref)
+		}
+		return execStore{args: args}, nil
+	default:
+		return nil, fmt.Errorf("unknown credential store %q (want keyring, file, config or exec:<command>)", ref)
+	}
+}
+
+// defaultSecretStore picks the most secure store available on this machine:
+// the OS keyring when it answers, otherwise the encrypted file.
+func defaultSecretStore() (secretStore, error) {
+	if keyringAvailable() {
+		return keyringStore{}, nil
+	}
+	return newSecretStore(storeFile)
+}
+
+func keyringAvailable() bool {
+	_, err := keyring.Get(keyringService, keyringUser)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// Where a credential came from, besides a secret store (see secretStore.String).
+const (
+	sourceEnv    = "env"
+	sourceConfig = "config"
+)
+
+// credential is the stored secret together with where it was found.
+type credential struct {
+	secret string
+	source string
+	// store is nil unless the secret came from credential_store.
+	store secretStore
+}
+
+// isOAuth reports whether the secret is an OAuth token rather than an API key.
+func (c credential) isOAuth() bool {
+	return c.store != nil && viper.GetString("auth_type") == authTypeOAuth
+}
+
+// describe returns a human-readable description of where the secret lives.
+func (c credential) describe() string {
+	switch {
+	case c.source == sourceEnv:
+		return "environment variable"
+	case c.source == sourceConfig:
+		return "config file"
+	case c.source == storeKeyring:
+		return "OS keyring"
+	case c.source == storeFile:
+		return "encrypted file"
+	case strings.HasPrefix(c.source, storeExec):
+		return "credential helper (" + strings.TrimPrefix(c.source, storeExec) + ")"
+	}
+	return c.source
+}
+
+// loadCredential returns the stored secret from the environment, a legacy
+// plaintext config entry, or the store referenced by credential_store, in that
+// order.
+func loadCredential() (credential, error) {
+	if apiKey := viper.GetString("api_key"); apiKey != "" {
+		source := sourceEnv
+		if viper.InConfig("api_key") {
+			source = sourceConfig
+		}
+		return credential{secret: apiKey, source: source}, nil
+	}
+	store, err := configuredSecretStore()
+	if err != nil {
+		return credential{}, err
+	}
+	if store == nil {
+		return credential{}, errNoAPIKey
+	}
+	secret, err := store.Get()
+	if errors.Is(err, errSecretNotFound) {
+		return credential{}, errNoAPIKey
+	}
+	if err != nil {
+		return credential{}, fmt.Errorf("failed to read credentials from %s: %w", store, err)
+	}
+	return credential{secret: secret, source: store.String(), store: store}, nil
+}
+
+var activeCred *credential
+
+// activeCredential loads the credential once per run, so a passphrase or
+// credential helper is only asked for once.
+func activeCredential() (credential, error) {
+	if activeCred != nil {
+		return *activeCred, nil
+	}
+	cred, err := loadCredential()
+	if err != nil {
+		return credential{}, err
+	}
+	activeCred = &cred
+	return cred, nil
+}
+
+// cacheScope names the account behind the credential without revealing it,
+// so each account's cached data is kept apart. OAuth tokens change on every
+// refresh, so they are scoped by the viewer recorded at login.
+func (c credential) cacheScope() string {
+	id := c.secret
+	if c.isOAuth() {
+		var token oauthToken
+		if err := json.Unmarshal([]byte(c.secret), &token); err == nil {
+			switch {
+			case token.Account != "":
+				id = token.Account
+			case token.RefreshToken != "":
+				id = token.RefreshToken
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// configuredSecretStore returns the store named by credential_store, or nil if
+// none is configured.
+func configuredSecretStore() (secretStore, error) {
+	ref := viper.GetString("credential_store")
+	if ref == "" {
+		return nil, nil
+	}
+	return newSecretStore(ref)
+}
+
+// keyringStore keeps the key in the OS keyring (Secret Service on Linux,
+// Keychain on macOS, Credential Manager on Windows).
+type keyringStore struct{}
+
+func (keyringStore) Get() (string, error) {
+	secret, err := keyring.Get(keyringService, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", errSecretNotFound
+	}
+	return secret, err
+}
+
+func (keyringStore) Set(secret string) error {
+	return keyring.Set(keyringService, keyringUser, secret)
+}
+
+func (keyringStore) Delete() error {
+	err := keyring.Delete(keyringService, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return errSecretNotFound
+	}
+	return err
+}
+
+func (keyringStore) String() string { return storeKeyring }
+
+// configStore is the original behaviour: api_key in plain text in config.yaml.
+type configStore struct{}
+
+func (configStore) Get() (string, error) {
+	if apiKey := viper.GetString("api_key"); apiKey != "" {
+		return apiKey, nil
+	}
+	return "", errSecretNotFound
+}
+
+func (configStore) Set(secret string) error {
+	return saveConfig(map[string]any{"api_key": secret})
+}
+
+func (configStore) Delete() error {
+	return removeConfigKey("api_key")
+}
+
+func (configStore) String() string { return storeConfig }
+
+// execStore delegates to an external helper, in the spirit of git credential
+// helpers: the helper is run with "get", "store" or "erase" as its last
+// argument. "get" prints the key on stdout, "store" reads it from stdin.
+type execStore struct {
+	args []string
+}
+
+func (s execStore) run(action string, stdin string) (string, error) {
+	c := exec.Command(s.args[0], append(s.args[1:], action)...)
+	c.Stdin = strings.NewReader(stdin)
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential helper %s %s: %w", s.args[0], action, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s execStore) Get() (string, error) {
+	secret, err := s.run("get", "")
+	if err != nil {
+		return "", err
+	}
+	if secret == "" {
+		return "", errSecretNotFound
+	}
+	return secret, nil
+}
+
+func (s execStore) Set(secret string) error {
+	_, err := s.run("store", secret+"\n")
+	return err
+}
+
+func (s execStore) Delete() error {
+	_, err := s.run("erase", "")
+	return err
+}
+
+func (s execStore) String() string { return storeExec + strings.Join(s.args, " ") }
+
+// encryptedFileStore keeps the key in an AES-GCM encrypted file next to the
+// config. The encryption key is derived from a passphrase taken from
+// QUICK_BRANCH_PASSPHRASE or prompted for on the terminal.
+type encryptedFileStore struct {
+	path       string
+	passphrase string
+}
+
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const pbkdf2Iterations = 600_000
+
+// credentialsFilePath puts the encrypted file next to the config file in
+// use, named after it, so each --config keeps its own key: work.yaml gets
+// work.credentials.enc. The default config.yaml keeps plain credentials.enc.
+func credentialsFilePath() (string, error) {
+	configPath, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+	dir, name := filepath.Split(configPath)
+	if name == "config.yaml" {
+		return filepath.Join(dir, "credentials.enc"), nil
+	}
+	return filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".credentials.enc"), nil
+}
+
+func (s *encryptedFileStore) readPassphrase(confirm bool) (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	if p := os.Getenv("QUICK_BRANCH_PASSPHRASE"); p != "" {
+		s.passphrase = p
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("set QUICK_BRANCH_PASSPHRASE to unlock %s", s.path)
+	}
+	fmt.Fprint(os.Stderr, "Credentials passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if !bytes.Equal(p, again) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if len(p) == 0 {
+		return "", errors.New("passphrase cannot be empty")
+	}
+	s.passphrase = string(p)
+	return s.passphrase, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *encryptedFileStore) Get() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", errSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("corrupt credentials file %s: %w", s.path, err)
+	}
+	passphrase, err := s.readPassphrase(false)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(passphrase, f.Salt)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return "", errors.New("wrong passphrase or corrupt credentials file")
+	}
+	return string(plaintext), nil
+}
+
+func (s *encryptedFileStore) Set(secret string) error {
+	passphrase, err := s.readPassphrase(true)
+	if err != nil {
+		return err
+	}
+	f := encryptedFile{Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, []byte(secret), nil)
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+func (s *encryptedFileStore) Delete() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return errSecretNotFound
+	}
+	return err
+}
+
+func (s *encryptedFileStore) String() string { return storeFile }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSecretStore(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "keyring", want: "keyring"},
+		{ref: "file", want: "file"},
+		{ref: "config", want: "config"},
+		{ref: "exec:pass-helper --vault work", want: "exec:pass-helper --vault work"},
+		{ref: "exec:  ", wantErr: true},
+		{ref: "vault", wantErr: true},
+	}
+	for _, tt := range tests {
+		store, err := newSecretStore(tt.ref)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("newSecretStore(%q) = %v, want an error", tt.ref, store)
+			}
+			continue
+		}
+		if err != nil || store.String() != tt.want {
+			t.Errorf("newSecretStore(%q) = %v, %v; want %s", tt.ref, store, err, tt.want)
+		}
+	}
+}
+
+// testSecretStore checks that store starts empty, keeps a secret, replaces
+// it, and forgets it on Delete.
+func testSecretStore(t *testing.T, store secretStore) {
+	t.Helper()
+	if _, err := store.Get(); !errors.Is(err, errSecretNotFound) {
+		t.Fatalf("Get on an empty store: %v, want errSecretNotFound", err)
+	}
+	for _, secret := range []string{"lin_api_first", `{"access_token":"a","refresh_token":"r"}`} {
+		if err := store.Set(secret); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		got, err := store.Get()
+		if err != nil || got != secret {
+			t.Fatalf("Get = %q, %v; want %q", got, err, secret)
+		}
+	}
+	if err := store.Delete(); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(); !errors.Is(err, errSecretNotFound) {
+		t.Fatalf("Get after Delete: %v, want errSecretNotFound", err)
+	}
+}
+
+func TestCredentialsFilePath(t *testing.T) {
+	home := isolateConfig(t)
+	t.Cleanup(func() { cfgFile = "" })
+
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{config: "", want: filepath.Join(home, "quick-branch", "credentials.enc")},
+		{config: "/etc/qb/work.yaml", want: "/etc/qb/work.credentials.enc"},
+		{config: "/etc/qb/config.yaml", want: "/etc/qb/credentials.enc"},
+		{config: "personal", want: "personal.credentials.enc"},
+	}
+	for _, tt := range tests {
+		cfgFile = tt.config
+		if got, err := credentialsFilePath(); err != nil || got != tt.want {
+			t.Errorf("with --config %q, credentialsFilePath() = %q, %v; want %q", tt.config, got, err, tt.want)
+		}
+	}
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quick-branch", "credentials.enc")
+	testSecretStore(t, &encryptedFileStore{path: path, passphrase: "correct horse"})
+
+	if err := (&encryptedFileStore{path: path, passphrase: "correct horse"}).Set("lin_api_secret"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("lin_api_secret")) {
+		t.Errorf("credentials file holds the key in plain text: %s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("credentials file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	if _, err := (&encryptedFileStore{path: path, passphrase: "wrong"}).Get(); err == nil {
+		t.Error("Get with the wrong passphrase succeeded")
+	}
+	t.Setenv("QUICK_BRANCH_PASSPHRASE", "correct horse")
+	if got, err := (&encryptedFileStore{path: path}).Get(); err != nil || got != "lin_api_secret" {
+		t.Errorf("Get with QUICK_BRANCH_PASSPHRASE = %q, %v", got, err)
+	}
+}
+
+func TestExecStore(t *testing.T) {
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper")
+	// A minimal credential helper keeping the secret in a file.
+	script := `#!/bin/sh
+vault="$1"
+case "$2" in
+get) if [ -f "$vault" ]; then cat "$vault"; fi ;;
+store) cat > "$vault" ;;
+erase) rm -f "$vault" ;;
+*) exit 2 ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := newSecretStore("exec:" + helper + " " + filepath.Join(dir, "vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSecretStore(t, store)
+
+	failing, err := newSecretStore("exec:" + helper + " " + filepath.Join(dir, "missing", "vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := failing.Set("lin_api_secret"); err == nil {
+		t.Error("Set succeeded although the helper failed")
+	}
+}
@@ -6,27 +6,61 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"os"
+	"regexp"
+	"strings"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/rangoons/quick-branch/internal/generated"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var (
 	status       bool
 	checkoutFlag bool
 	turbo        bool
+	openStarted  bool
 )
 
 // startCmd represents the start command
 var startCmd = &cobra.Command{
-	Use:   "start <issueID>",
-	Short: "start will assign you to the issue you pass in",
-	Long:  ``,
-	Args:  cobra.ExactArgs(1),
+	Use:   "start <issueID>...",
+	Short: "start will assign you to the issues you pass in",
+	Long: `start assigns you to an issue and can move it to 'In Progress' and check
+out its branch.
+
+Given several issues (or --from-list), start assigns and optionally moves
+them all at once after showing a preview; --checkout then isn't available.
+With --dry-run only the preview is shown, for one issue or many.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !fromList {
+			return fmt.Errorf("pass one or more issue identifiers, or --from-list")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		bulk := len(args) > 1 || fromList
+		if bulk || dryRun {
+			if bulk && checkoutFlag {
+				fmt.Println("Error: --checkout works with a single issue")
+				return
+			}
+			if bulk && openStarted {
+				fmt.Println("Error: --open works with a single issue")
+				return
+			}
+			// Turbo's checkout step doesn't apply to several issues, and
+			// a dry run checks nothing out.
+			if turbo {
+				status = true
+			}
+			if err := runBulk(cmd.Context(), args, startPlan); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			return
+		}
 		issueID := args[0]
 
 		// Turbo mode enables both status and checkout
@@ -35,37 +69,33 @@ var startCmd = &cobra.Command{
 			checkoutFlag = true
 		}
 
-		err := assignMe(issueID)
+		// Assignment and the status change go out as one issueUpdate, which
+		// also returns the branch name, so turbo mode is usually a single
+		// request once the viewer and team states are cached.
+		issue, err := assignMe(cmd.Context(), issueID, status)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
-		if status {
-			err := updateIssueStatus(issueID)
-			if err != nil {
-				fmt.Println(err)
-				return
-			}
-		}
 		if checkoutFlag {
-			issue, err := fetchIssue(issueID)
-			if err != nil {
-				fmt.Printf("Error fetching issue: %v\n", err)
-				return
-			}
 			if err := checkoutBranch(issue.BranchName); err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 		}
+		if openStarted {
+			openURL(issue.Url)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(startCmd)
 
-	startCmd.Flags().BoolVarP(&turbo, "turbo", "t", false, "Assigns you to the issue, updates status to 'In Progress', and checks out the branch (all-in-one!)")
-	startCmd.Flags().BoolVarP(&status, "status", "s", false, "Updates the status of the issue to 'In Progress'")
+	startCmd.Flags().BoolVarP(&turbo, "turbo", "t", false, "Assigns you to the issue, updates status to 'In Progress' (or states.in_progress), and checks out the branch (all-in-one!)")
+	startCmd.Flags().BoolVarP(&status, "status", "s", false, "Updates the status of the issue to 'In Progress' (or states.in_progress)")
 	startCmd.Flags().BoolVarP(&checkoutFlag, "checkout", "c", false, "Creates a new branch in the cwd using the branch name from linear")
+	startCmd.Flags().BoolVarP(&openStarted, "open", "o", false, "Opens the issue in your browser")
+	addBulkFlags(startCmd.Flags())
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -77,65 +107,170 @@ func init() {
 	// startCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func assignMe(issueID string) error {
-	apiKey := viper.GetString("api_key")
-	if apiKey == "" {
-		return fmt.Errorf("no API key found. Please run 'quick-branch auth' first")
-	}
-	httpClient := &http.Client{
-		Transport: &authorizedTransport{
-			apiKey: apiKey,
-			base:   http.DefaultTransport,
-		},
+// assignMe assigns the viewer to issueID and, with setStatus, moves it to the
+// in-progress state in the same mutation.
+func assignMe(ctx context.Context, issueID string, setStatus bool) (*generated.IssueUpdateIssueUpdateIssuePayloadIssue, error) {
+	graphqlClient, err := newGraphQLClient()
+	if err != nil {
+		return nil, err
 	}
 
-	graphqlClient := graphql.NewClient("https://api.linear.app/graphql", httpClient)
+	// Look for blockers before changing anything, so starting a blocked
+	// issue can still be called off.
+	current, err := fetchIssue(ctx, issueID)
+	if err != nil {
+		return nil, err
+	}
+	if blockers := unfinishedBlockers(current.InverseRelations.Nodes); len(blockers) > 0 {
+		warnIfBlocked(current.Identifier, blockers)
+		if !yes && term.IsTerminal(int(os.Stdin.Fd())) {
+			ok, err := confirm(fmt.Sprintf("Start %s anyway?", current.Identifier))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("%s was not started", current.Identifier)
+			}
+		}
+	}
 
-	ctx := context.Background()
-	response, err := generated.Me(ctx, graphqlClient)
+	viewer, err := fetchViewer(ctx, graphqlClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	input := generated.IssueUpdateInput{AssigneeId: &response.Viewer.Id}
+	input := generated.IssueUpdateInput{AssigneeId: &viewer.Id}
+	if setStatus {
+		stateID, err := inProgressStateID(ctx, graphqlClient, issueID)
+		if err != nil {
+			return nil, err
+		}
+		input.StateId = &stateID
+	}
+
 	mutation, err := generated.IssueUpdate(ctx, graphqlClient, issueID, input)
 	if err != nil {
-		return err
+		return nil, issueError(err, issueID)
+	}
+	issue := mutation.IssueUpdate.Issue
+	if issue == nil {
+		invalidateIssueCache(issueID)
+		return nil, fmt.Errorf("issue %s could not be updated", issueID)
+	}
+	invalidateIssueCache(issueID, issue.Id, issue.Identifier)
+	fmt.Printf("Success! Assigned %v to %v\n", viewer.Name, issue.Title)
+	if setStatus {
+		fmt.Printf("Success! Updated %v to %v\n", issue.Title, issue.State.Name)
 	}
-	fmt.Printf("Success! Assigned %v to %v\n", response.Viewer.Name, mutation.IssueUpdate.Issue.Title)
-	return nil
+	return issue, nil
 }
 
-func updateIssueStatus(issueID string) error {
-	apiKey := viper.GetString("api_key")
-	if apiKey == "" {
-		return fmt.Errorf("no API key found. Please run 'quick-branch auth' first")
+// startPlan assigns the viewer to every issue and, with --status, moves
+// each one to its team's in-progress state.
+func startPlan(ctx context.Context, client graphql.Client, issues []generated.BulkIssueFields) ([]bulkChange, error) {
+	viewer, err := fetchViewer(ctx, client)
+	if err != nil {
+		return nil, err
 	}
-	httpClient := &http.Client{
-		Transport: &authorizedTransport{
-			apiKey: apiKey,
-			base:   http.DefaultTransport,
-		},
+	changes := make([]bulkChange, len(issues))
+	for i, issue := range issues {
+		c := bulkChange{
+			issue: issue,
+			input: generated.IssueUpdateInput{AssigneeId: &viewer.Id},
+			from:  "unassigned",
+			to:    viewer.Name,
+		}
+		if issue.Assignee != nil {
+			c.from = issue.Assignee.Name
+		}
+		assigned := issue.Assignee != nil && issue.Assignee.Id == viewer.Id
+		if assigned {
+			c.skip = "already assigned to you"
+		}
+		for _, b := range unfinishedBlockers(issue.InverseRelations.Nodes) {
+			c.blockedBy = append(c.blockedBy, b.Identifier)
+		}
+		if status {
+			states, err := fetchTeamStates(ctx, client, issue.Team.Id)
+			if err != nil {
+				return nil, err
+			}
+			state, err := inProgressState(states)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", issue.Identifier, err)
+			}
+			c.input.StateId = &state.Id
+			c.from += ", " + issue.State.Name
+			c.to += ", " + state.Name
+			c.skip = ""
+			if assigned && issue.State.Id == state.Id {
+				c.skip = "already assigned to you and " + state.Name
+			}
+		}
+		changes[i] = c
 	}
+	return changes, nil
+}
 
-	graphqlClient := graphql.NewClient("https://api.linear.app/graphql", httpClient)
+// fetchViewer returns the authenticated user, from the cache when fresh.
+func fetchViewer(ctx context.Context, client graphql.Client) (generated.MeViewerUser, error) {
+	viewer, _, err := cached("viewer", func() (generated.MeViewerUser, error) {
+		response, err := generated.Me(ctx, client)
+		if err != nil {
+			return generated.MeViewerUser{}, err
+		}
+		return response.Viewer, nil
+	})
+	return viewer, err
+}
 
-	ctx := context.Background()
-	response, err := generated.TeamStates(ctx, graphqlClient, issueID)
+// inProgressStateID returns the ID of the states.in_progress workflow state
+// on issueID's team.
+func inProgressStateID(ctx context.Context, client graphql.Client, issueID string) (string, error) {
+	states, err := issueTeamStates(ctx, client, issueID)
 	if err != nil {
-		return err
+		return "", err
+	}
+	state, err := inProgressState(states)
+	return state.Id, err
+}
+
+// inProgressState picks the states.in_progress state out of a team's states.
+func inProgressState(states []generated.WorkflowStateFields) (generated.WorkflowStateFields, error) {
+	target := viper.GetString("states.in_progress")
+	if target == "" {
+		target = "In Progress"
+	}
+	state, ok := findState(states, target)
+	if !ok {
+		return state, fmt.Errorf("no workflow state named %q on this issue's team. Set states.in_progress to the right name", target)
 	}
-	var inProgress string
-	for _, s := range response.Issue.Team.States.Nodes {
-		if s.Name == "In Progress" {
-			inProgress = s.Id
+	return state, nil
+}
+
+// issueKeyPattern matches human-readable identifiers like ENG-123, whose
+// prefix is the team key.
+var issueKeyPattern = regexp.MustCompile(`^([A-Za-z0-9]+)-\d+$`)
+
+// issueTeamStates returns the workflow states of issueID's team. States are
+// cached by team key, so any issue of an already-seen team costs no request.
+func issueTeamStates(ctx context.Context, client graphql.Client, issueID string) ([]generated.WorkflowStateFields, error) {
+	fetch := func() ([]generated.WorkflowStateFields, error) {
+		response, err := generated.TeamStates(ctx, client, issueID)
+		if err != nil {
+			return nil, issueError(err, issueID)
 		}
+		nodes := response.Issue.Team.States.Nodes
+		states := make([]generated.WorkflowStateFields, len(nodes))
+		for i, n := range nodes {
+			states[i] = n.WorkflowStateFields
+		}
+		return states, nil
 	}
 
-	input := generated.IssueUpdateInput{StateId: &inProgress}
-	mutation, err := generated.IssueUpdate(ctx, graphqlClient, issueID, input)
-	if err != nil {
-		return err
+	m := issueKeyPattern.FindStringSubmatch(issueID)
+	if m == nil {
+		return fetch()
 	}
-	fmt.Printf("Success! Updated %v to %v\n", mutation.IssueUpdate.Issue.Title, mutation.IssueUpdate.Issue.State.Name)
-	return nil
+	states, _, err := cached("states-key-"+strings.ToUpper(m[1]), fetch)
+	return states, err
 }
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+const (
+	linearAPIURL = "https://api.linear.app/graphql"
+
+	maxRetries     = 3
+	retryBaseDelay = 300 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+
+	// Once fewer than rateLimitLowWater requests (or complexity points, as a
+	// fraction of the limit) remain in the window, requests are spread out
+	// over the time left until the reset, waiting at most rateLimitMaxPause.
+	rateLimitLowWater = 0.05
+	rateLimitMaxPause = 2 * time.Second
+)
+
+var requestTimeout time.Duration
+
+// newLinearClient returns a GraphQL client for the Linear API that
+// authenticates with auth, retries failed queries and respects rate limits.
+func newLinearClient(auth *authorizedTransport) graphql.Client {
+	transport := &retryTransport{base: withFixtures(auth)}
+	httpClient := &http.Client{Transport: transport}
+	return &linearClient{
+		Client:    graphql.NewClient(linearAPIURL, httpClient),
+		transport: transport,
+	}
+}
+
+// linearClient applies --timeout to each operation and turns Linear's error
+// responses into readable errors (see translateError).
+type linearClient struct {
+	graphql.Client
+	transport *retryTransport
+}
+
+func (c *linearClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	if requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+	err := c.Client.MakeRequest(ctx, req, resp)
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The *url.Error stays reachable so cached can fall back to stale data.
+		return &linearError{
+			message: fmt.Sprintf("request to Linear timed out after %s (change with --timeout)", requestTimeout),
+			err:     err,
+		}
+	}
+	if hasErrorCode(err, "RATELIMITED") {
+		return &rateLimitError{reset: c.transport.limits.resetTime()}
+	}
+	return translateError(err)
+}
+
+func hasErrorCode(err error, code string) bool {
+	for _, e := range graphQLErrors(err) {
+		if c, _ := e.Extensions["code"].(string); c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitError is returned once Linear refuses requests for the rest of the
+// rate-limit window.
+type rateLimitError struct {
+	reset time.Time
+}
+
+func (e *rateLimitError) Error() string {
+	if e.reset.IsZero() {
+		return "Linear API rate limit exceeded; try again in a few minutes"
+	}
+	return fmt.Sprintf("Linear API rate limit exceeded; it resets at %s (in %s)",
+		e.reset.Local().Format("15:04:05"), time.Until(e.reset).Round(time.Second))
+}
+
+// retryTransport retries queries (never mutations) that fail with a network
+// error or a 5xx response, with jittered exponential backoff, and slows down
+// as Linear's rate limit runs low.
+type retryTransport struct {
+	base   http.RoundTripper
+	limits rateLimits
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	idempotent := isGraphQLQuery(body)
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := sleep(ctx, t.limits.pause()); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req.Clone(ctx)
+		attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		attemptReq.ContentLength = int64(len(body))
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if resp != nil {
+			t.limits.update(resp.Header)
+		}
+
+		retryable := (err != nil && ctx.Err() == nil) ||
+			(err == nil && resp.StatusCode >= http.StatusInternalServerError)
+		if !idempotent || !retryable || attempt == maxRetries {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(after) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isGraphQLQuery reports whether a GraphQL request body holds a query, which
+// is safe to send again, rather than a mutation.
+func isGraphQLQuery(body []byte) bool {
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(payload.Query), "query")
+}
+
+// backoff returns the delay before retry number attempt+1: exponential with
+// full jitter between half and all of the step.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimits tracks Linear's X-RateLimit-* response headers for both the
+// request and complexity budgets.
+type rateLimits struct {
+	mu       sync.Mutex
+	requests rateWindow
+	points   rateWindow
+}
+
+type rateWindow struct {
+	limit, remaining int
+	reset            time.Time
+}
+
+func (w rateWindow) known() bool { return w.limit > 0 }
+
+// pause returns how long to wait so the remaining budget lasts until reset.
+func (w rateWindow) pause() time.Duration {
+	if !w.known() || float64(w.remaining) > float64(w.limit)*rateLimitLowWater {
+		return 0
+	}
+	d := time.Until(w.reset) / time.Duration(w.remaining+1)
+	return min(max(d, 0), rateLimitMaxPause)
+}
+
+func (l *rateLimits) update(h http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	parseRateWindow(h, "Requests", &l.requests)
+	parseRateWindow(h, "Complexity", &l.points)
+}
+
+func parseRateWindow(h http.Header, kind string, w *rateWindow) {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-" + kind + "-Limit"))
+	if err != nil {
+		return
+	}
+	w.limit = limit
+	w.remaining, _ = strconv.Atoi(h.Get("X-RateLimit-" + kind + "-Remaining"))
+	if ms, err := strconv.ParseInt(h.Get("X-RateLimit-"+kind+"-Reset"), 10, 64); err == nil {
+		w.reset = time.UnixMilli(ms)
+	}
+}
+
+func (l *rateLimits) pause() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return max(l.requests.pause(), l.points.pause())
+}
+
+// resetTime returns when the exhausted window resets, or the zero time if
+// that isn't known.
+func (l *rateLimits) resetTime() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.points.known() && l.points.remaining <= 0 {
+		return l.points.reset
+	}
+	return l.requests.reset
+}
+
+// fetchPages collects every page of a connection. fetch is given the cursor
+// to continue after, nil for the first page, and returns that page's items
+// and page info.
+func fetchPages[T any](fetch func(after *string) ([]T, generated.PageInfoFields, error)) ([]T, error) {
+	var all []T
+	var after *string
+	for {
+		items, page, err := fetch(after)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if !page.HasNextPage || page.EndCursor == nil {
+			return all, nil
+		}
+		after = page.EndCursor
+	}
+}
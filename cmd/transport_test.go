@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 6 {
+		step := min(retryBaseDelay<<attempt, retryMaxDelay)
+		for range 20 {
+			if d := backoff(attempt); d < step/2 || d > step {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, d, step/2, step)
+			}
+		}
+	}
+}
+
+func TestIsGraphQLQuery(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"query":"query Me { viewer { id } }"}`, true},
+		{`{"query":"  query Issue($id: String!) { issue(id: $id) { id } }"}`, true},
+		{`{"query":"mutation IssueUpdate { issueUpdate { success } }"}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if got := isGraphQLQuery([]byte(tt.body)); got != tt.want {
+			t.Errorf("isGraphQLQuery(%s) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+// scriptedTransport answers with each status in turn, repeating the last.
+type scriptedTransport struct {
+	statuses []int
+	err      error
+	calls    int
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	status := s.statuses[min(s.calls, len(s.statuses))-1]
+	return &http.Response{
+		StatusCode: status,
+		// Retry at once rather than after a real backoff.
+		Header:  http.Header{"Retry-After": {"0"}},
+		Body:    io.NopCloser(strings.NewReader("{}")),
+		Request: req,
+	}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	const query = `{"query":"query Me { viewer { id } }"}`
+	const mutation = `{"query":"mutation IssueUpdate { issueUpdate { success } }"}`
+
+	tests := []struct {
+		name       string
+		body       string
+		statuses   []int
+		err        error
+		wantCalls  int
+		wantStatus int
+	}{
+		{name: "success", body: query, statuses: []int{200}, wantCalls: 1, wantStatus: 200},
+		{name: "query retried after 5xx", body: query, statuses: []int{502, 503, 200}, wantCalls: 3, wantStatus: 200},
+		{name: "query gives up", body: query, statuses: []int{500}, wantCalls: maxRetries + 1, wantStatus: 500},
+		{name: "4xx not retried", body: query, statuses: []int{400}, wantCalls: 1, wantStatus: 400},
+		{name: "mutation not retried", body: mutation, statuses: []int{500}, wantCalls: 1, wantStatus: 500},
+		{name: "mutation network error not retried", body: mutation, err: errors.New("connection reset"), wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &scriptedTransport{statuses: tt.statuses, err: tt.err}
+			req, err := http.NewRequest(http.MethodPost, linearAPIURL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&retryTransport{base: base}).RoundTrip(req)
+			if base.calls != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", base.calls, tt.wantCalls)
+			}
+			if tt.err != nil {
+				if err == nil {
+					t.Errorf("got no error, want %v", tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got HTTP %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRetryTransportNetworkError(t *testing.T) {
+	// Network errors are retried with the real backoff, so keep it to one.
+	base := &scriptedTransport{err: errors.New("connection reset")}
+	req, _ := http.NewRequest(http.MethodPost, linearAPIURL, strings.NewReader(`{"query":"query Me { viewer { id } }"}`))
+	if _, err := (&retryTransport{base: base}).RoundTrip(req); err == nil {
+		t.Fatal("got no error after every attempt failed")
+	}
+	if base.calls != maxRetries+1 {
+		t.Errorf("sent %d requests, want %d", base.calls, maxRetries+1)
+	}
+}
+
+func rateHeaders(kind string, limit, remaining int, reset time.Time) http.Header {
+	h := http.Header{}
+	h.Set("X-RateLimit-"+kind+"-Limit", strconv.Itoa(limit))
+	h.Set("X-RateLimit-"+kind+"-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-"+kind+"-Reset", strconv.FormatInt(reset.UnixMilli(), 10))
+	return h
+}
+
+func TestParseRateWindow(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	var w rateWindow
+	parseRateWindow(rateHeaders("Requests", 1500, 1200, reset), "Requests", &w)
+	if w.limit != 1500 || w.remaining != 1200 || !w.reset.Equal(reset) {
+		t.Errorf("parsed %+v, want limit 1500, remaining 1200, reset %s", w, reset)
+	}
+
+	// Headers for another kind, or none at all, leave the window alone.
+	before := w
+	parseRateWindow(rateHeaders("Complexity", 10, 1, reset), "Requests", &w)
+	parseRateWindow(http.Header{}, "Requests", &w)
+	if w != before {
+		t.Errorf("unrelated headers changed the window to %+v", w)
+	}
+}
+
+func TestRateLimitsPause(t *testing.T) {
+	soon := time.Now().Add(10 * time.Second)
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		headers   http.Header
+		wantPause bool
+		wantReset time.Time
+	}{
+		{name: "no headers", headers: http.Header{}},
+		{name: "plenty left", headers: rateHeaders("Requests", 1500, 1000, soon), wantReset: soon},
+		{name: "requests low", headers: rateHeaders("Requests", 1500, 10, soon), wantPause: true, wantReset: soon},
+		{name: "complexity exhausted", headers: func() http.Header {
+			h := rateHeaders("Requests", 1500, 1000, soon)
+			for k, v := range rateHeaders("Complexity", 250000, 0, later) {
+				h[k] = v
+			}
+			return h
+		}(), wantPause: true, wantReset: later},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l rateLimits
+			l.update(tt.headers)
+			pause := l.pause()
+			if (pause > 0) != tt.wantPause || pause > rateLimitMaxPause {
+				t.Errorf("pause() = %s, want pause %v of at most %s", pause, tt.wantPause, rateLimitMaxPause)
+			}
+			if got := l.resetTime(); !got.Equal(tt.wantReset.Truncate(time.Millisecond)) {
+				t.Errorf("resetTime() = %s, want %s", got, tt.wantReset)
+			}
+		})
+	}
+}
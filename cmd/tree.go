@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/rangoons/quick-branch/internal/generated"
+)
+
+// treeDepth is how many levels of sub-issues the IssueTree query fetches.
+const treeDepth = 3
+
+// issueTree is an issue with its parent chain and sub-issues. genqlient
+// gives every nesting level of the IssueTree query its own type, so the
+// response is decoded into this one recursive type instead.
+type issueTree struct {
+	generated.TreeIssueFields
+	Parent   *issueTree `json:"parent"`
+	Children struct {
+		Nodes []issueTree `json:"nodes"`
+	} `json:"children"`
+}
+
+// fetchIssueTree returns issueID with up to three ancestors and three levels
+// of sub-issues, from the cache when fresh.
+func fetchIssueTree(ctx context.Context, client graphql.Client, issueID string) (issueTree, error) {
+	t, _, err := cached("issue-"+strings.ToUpper(issueID)+".tree", func() (issueTree, error) {
+		response, err := generated.IssueTree(ctx, client, issueID)
+		if err != nil {
+			return issueTree{}, issueError(err, issueID)
+		}
+		// genqlient's MarshalJSON, which flattens fragments, has a pointer
+		// receiver.
+		data, err := json.Marshal(&response.Issue)
+		if err != nil {
+			return issueTree{}, err
+		}
+		var t issueTree
+		err = json.Unmarshal(data, &t)
+		return t, err
+	})
+	return t, err
+}
+
+// printIssueTree draws the issue under its parents, with its sub-issues
+// below it, then how many of its direct sub-issues are done.
+func printIssueTree(t issueTree) {
+	node := issueSubtree(t, 0).
+		RootStyle(lipgloss.NewStyle().Bold(true))
+
+	for p := t.Parent; p != nil; p = p.Parent {
+		if p.Title == "" {
+			// Past the fetched ancestors: only the identifier is known.
+			node = tree.Root(mutedStyle.Render("… " + p.Identifier)).Child(node)
+			break
+		}
+		node = tree.Root(treeLabel(p.TreeIssueFields)).Child(node)
+	}
+	fmt.Println(node)
+
+	if n := len(t.Children.Nodes); n > 0 {
+		done := 0
+		for _, c := range t.Children.Nodes {
+			if c.State.Type == "completed" {
+				done++
+			}
+		}
+		fmt.Println()
+		fmt.Printf("%d of %s done\n", done, plural(n, "sub-issue"))
+	}
+}
+
+// issueSubtree renders t and its sub-issues, summarising any levels below
+// what IssueTree fetched.
+func issueSubtree(t issueTree, depth int) *tree.Tree {
+	node := tree.Root(treeLabel(t.TreeIssueFields))
+	if depth == treeDepth {
+		if n := len(t.Children.Nodes); n > 0 {
+			node.Child(mutedStyle.Render(fmt.Sprintf("+%s (issue %s --tree)", plural(n, "sub-issue"), t.Identifier)))
+		}
+		return node
+	}
+	for _, c := range t.Children.Nodes {
+		node.Child(issueSubtree(c, depth+1))
+	}
+	return node
+}
+
+// treeLabel is one line of the tree: identifier, title, state and assignee.
+func treeLabel(i generated.TreeIssueFields) string {
+	state := lipgloss.NewStyle().Foreground(lipgloss.Color(i.State.Color)).Render(i.State.Name)
+	assignee := mutedStyle.Render("unassigned")
+	if i.Assignee != nil {
+		assignee = i.Assignee.Name
+	}
+	return fmt.Sprintf("%s %s · %s · %s", i.Identifier, truncate(i.Title, 50), state, assignee)
+}
+
+// fetchChildren returns parent's direct sub-issues, from the cache when
+// fresh.
+func fetchChildren(ctx context.Context, client graphql.Client, parent *generated.IssueIssue) ([]generated.FilteredIssuesIssuesIssueConnectionNodesIssue, error) {
+	children, _, err := cached("issues-children-"+parent.Identifier, func() ([]generated.FilteredIssuesIssuesIssueConnectionNodesIssue, error) {
+		filter := &generated.IssueFilter{
+			Parent: &generated.NullableIssueFilter{Id: &generated.IDComparator{Eq: &parent.Id}},
+		}
+		response, err := generated.FilteredIssues(ctx, client, filter)
+		if err != nil {
+			return nil, err
+		}
+		return response.Issues.Nodes, nil
+	})
+	return children, err
+}